@@ -0,0 +1,101 @@
+package main
+
+import (
+	"math"
+)
+
+// Abs variants of the Mandelbrot set, selected by their algorithm names
+const (
+	burningShipAlgoValue       = "ship"
+	tricornAlgoValue           = "tricorn"
+	celticAlgoValue            = "celtic"
+	buffaloAlgoValue           = "buffalo"
+	perpendicularAlgoValue     = "perpendicular"
+	perpendicularShipAlgoValue = "perpendicular_ship"
+	heartAlgoValue             = "heart"
+)
+
+// An absVariant is a variation on z**2 + c that takes the absolute value of, or negates, parts
+// of z or z**2 along the way. Every variant squares the modulus of z, so they share the escape
+// radii of the Mandelbrot set.
+type absVariant struct {
+	name  string                                                              // Prefix of generated file names
+	step  func(x float64, y float64, a float64, b float64) (float64, float64) // One iteration from z = x + yi with c = a + bi
+	plane Plane                                                               // Default plane, framing the whole set
+}
+
+var absVariants = map[string]absVariant{
+	// (|x| + |y|i)**2 + c, which references draw with the imaginary axis running down the
+	// image. This iterates its conjugate so the ship is upright with the axis running up, so
+	// a feature references place at a + bi is at a - bi here, both for -i and in what
+	// -mode coordsAt reports.
+	burningShipAlgoValue: {"ship_", func(x float64, y float64, a float64, b float64) (float64, float64) {
+		return x*x - y*y + a, -2*math.Abs(x*y) + b
+	}, Plane{-2.5, 1.5, -1.25, 2.25}},
+	// The Tricorn, or Mandelbar, conj(z)**2 + c
+	tricornAlgoValue: {"tricorn_", func(x float64, y float64, a float64, b float64) (float64, float64) {
+		return x*x - y*y + a, -2*x*y + b
+	}, Plane{-2.25, 2.25, -2.25, 2.25}},
+	// |Re(z**2)| + Im(z**2)i + c
+	celticAlgoValue: {"celtic_", func(x float64, y float64, a float64, b float64) (float64, float64) {
+		return math.Abs(x*x-y*y) + a, 2*x*y + b
+	}, Plane{-2.5, 1.5, -2.0, 2.0}},
+	// |Re(z**2)| + |Im(z**2)|i + c
+	buffaloAlgoValue: {"buffalo_", func(x float64, y float64, a float64, b float64) (float64, float64) {
+		return math.Abs(x*x-y*y) + a, 2*math.Abs(x*y) + b
+	}, Plane{-2.5, 1.5, -2.5, 1.5}},
+	// x**2 - y**2 - 2|x|yi + c
+	perpendicularAlgoValue: {"perpendicular_", func(x float64, y float64, a float64, b float64) (float64, float64) {
+		return x*x - y*y + a, -2*math.Abs(x)*y + b
+	}, Plane{-2.5, 1.5, -2.0, 2.0}},
+	// x**2 - y**2 - 2x|y|i + c
+	perpendicularShipAlgoValue: {"perpendicular_ship_", func(x float64, y float64, a float64, b float64) (float64, float64) {
+		return x*x - y*y + a, -2*x*math.Abs(y) + b
+	}, Plane{-2.5, 1.5, -2.0, 2.0}},
+	// x**2 - y**2 + 2|x|yi + c
+	heartAlgoValue: {"heart_", func(x float64, y float64, a float64, b float64) (float64, float64) {
+		return x*x - y*y + a, 2*math.Abs(x)*y + b
+	}, Plane{-2.0, 1.0, -1.5, 1.5}},
+}
+
+// absVariantNames returns the algorithm names of the abs variants
+func absVariantNames() []string {
+	return []string{burningShipAlgoValue, tricornAlgoValue, celticAlgoValue, buffaloAlgoValue, perpendicularAlgoValue, perpendicularShipAlgoValue, heartAlgoValue}
+}
+
+// An absVariantPlane represents the strongly typed planar space for an abs variant
+type absVariantPlane struct {
+	Plane
+	variant absVariant
+}
+
+func newAbsVariant(v absVariant) absVariantPlane {
+	return absVariantPlane{v.plane, v}
+}
+
+func (m *absVariantPlane) process(c config) {
+	m.processFormula(c, m.formula(c))
+}
+
+func (m *absVariantPlane) formula(c config) formula {
+	return formula{
+		name:         m.variant.name,
+		orbit:        m.variant.orbit,
+		start:        startAtZero,
+		bailout:      c.bailout * c.bailout,
+		juliaBailout: determineJuliaBailout,
+		degree:       2.0,
+		juliaPlane:   Plane{-2.0, 2.0, -2.0, 2.0},
+	}
+}
+
+// orbit iterates the variant from z until it passes the bailout
+func (v absVariant) orbit(x float64, y float64, a float64, b float64, bailout float64, config config) (bool, int, float64, float64) {
+	var iteration int
+
+	for iteration = 0; x*x+y*y <= bailout && iteration < config.maxIterations; iteration++ {
+		x, y = v.step(x, y, a, b)
+	}
+
+	return iteration < config.maxIterations, iteration, x, y
+}
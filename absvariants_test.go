@@ -0,0 +1,90 @@
+package main
+
+import (
+	"math"
+	"testing"
+)
+
+func TestAbsVariantStepsFromAReferencePoint(t *testing.T) {
+	// One step from z = c = 0.1 - 0.2i, where x**2 - y**2 = -0.03 and xy = -0.02
+	expected := map[string][2]float64{
+		burningShipAlgoValue:       {0.07, -0.24},
+		tricornAlgoValue:           {0.07, -0.16},
+		celticAlgoValue:            {0.13, -0.24},
+		buffaloAlgoValue:           {0.13, -0.16},
+		perpendicularAlgoValue:     {0.07, -0.16},
+		perpendicularShipAlgoValue: {0.07, -0.24},
+		heartAlgoValue:             {0.07, -0.24},
+	}
+
+	for _, name := range absVariantNames() {
+		x, y := absVariants[name].step(0.1, -0.2, 0.1, -0.2)
+		want := expected[name]
+		if math.Abs(x-want[0]) > 1e-12 || math.Abs(y-want[1]) > 1e-12 {
+			t.Errorf("%s stepped to %f%+fi, want %f%+fi.", name, x, y, want[0], want[1])
+		}
+	}
+}
+
+func TestAbsVariantsAgreeWithMandelbrotOnTheRealAxis(t *testing.T) {
+	m := newMandelbrot()
+	var c config
+	c.maxIterations = 1000
+	c.bailout = 4.0
+
+	for _, name := range absVariantNames() {
+		v := newAbsVariant(absVariants[name])
+		f := v.formula(c).mandelbrotForm()
+
+		// c = 0 and c = -1 are fixed and period two points for every variant
+		for _, r := range []float64{0.0, -1.0} {
+			if escaped, iterations, _, _ := f.calculate(r, 0.0, c); escaped {
+				t.Errorf("%s escaped from %f after %d iterations, want it bounded.", name, r, iterations)
+			}
+		}
+
+		// Past 1/4 the real axis escapes, at the same count as the Mandelbrot set
+		escaped, iterations, _, _ := f.calculate(0.5, 0.0, c)
+		_, expectedIterations, _, _ := m.calculateEscape(0.5, 0.0, c)
+		if !escaped || iterations != expectedIterations-1 {
+			t.Errorf("%s gave %t after %d iterations at 0.5, want to escape after %d.", name, escaped, iterations, expectedIterations-1)
+		}
+	}
+}
+
+func TestBurningShipIsNotMirrored(t *testing.T) {
+	v := newAbsVariant(absVariants[burningShipAlgoValue])
+	var c config
+	c.maxIterations = 1000
+	c.bailout = 4.0
+	f := v.formula(c).mandelbrotForm()
+
+	// The tip of the ship's antenna is at -2, while +2 escapes at once
+	if escaped, iterations, _, _ := f.calculate(-1.99, 0.0, c); escaped {
+		t.Errorf("Ship escaped from -1.99 after %d iterations, want it bounded.", iterations)
+	}
+
+	if escaped, _, _, _ := f.calculate(1.99, 0.0, c); !escaped {
+		t.Errorf("Ship stayed bounded at 1.99, want it to escape.")
+	}
+}
+
+func TestBurningShipIsUpright(t *testing.T) {
+	v := newAbsVariant(absVariants[burningShipAlgoValue])
+	var c config
+	c.maxIterations = 1000
+	c.bailout = 4.0
+	f := v.formula(c).mandelbrotForm()
+
+	// The mast of the small ship on the antenna rises from the hull at about -1.74 + 0.02i,
+	// and the hull of the big ship lies around -0.5 + 0.5i. Mirrored, both escape.
+	for _, p := range [][2]float64{{-1.7412, 0.025}, {-0.5, 0.5}} {
+		if escaped, iterations, _, _ := f.calculate(p[0], p[1], c); escaped {
+			t.Errorf("Ship escaped from %v after %d iterations, want it bounded.", p, iterations)
+		}
+
+		if escaped, _, _, _ := f.calculate(p[0], -p[1], c); !escaped {
+			t.Errorf("Ship stayed bounded at %f%+fi, want it to escape.", p[0], -p[1])
+		}
+	}
+}
@@ -1,9 +1,7 @@
 package main
 
 import (
-	"fmt"
 	"math/cmplx"
-	"strconv"
 )
 
 type boojee struct {
@@ -15,59 +13,31 @@ func newboojee() boojee {
 }
 
 func (m boojee) process(c config) {
-	if c.midX == -99.0 {
-		c.midX = (m.rMax + m.rMin) / 2.0
-	}
-
-	if c.midY == -99.0 {
-		c.midY = (m.iMax + m.iMin) / 2.0
-	}
+	m.processFormula(c, m.formula())
+}
 
-	if c.mode == imageMode {
-		m.image(c)
-	} else if c.mode == coordinatesMode {
-		var r, i = m.calculateCoordinatesAtPoint(c)
-		fmt.Printf("%18.17e, %18.17e\n", r, i)
+// boujee has no c, so its Julia form is the same picture as its Mandelbrot form
+func (m *boojee) formula() formula {
+	return formula{
+		name:         "boujee_mb_",
+		orbit:        boujeeOrbit,
+		start:        startAtC,
+		juliaBailout: func(cReal float64, cImag float64) float64 { return 0.0 },
+		juliaPlane:   m.Plane,
 	}
 }
 
-func (m *boojee) image(c config) {
-	initialiseGradient(c.gradient)
-
-	mbi := initialiseimage(c)
-
-	plottedChannel := make(chan PlottedPoint)
-
-	go func(points <-chan PlottedPoint) {
-		for p := range points {
-			if p.Escaped {
-				mbi.Set(p.X, p.Y, getPixelColour(p, c.maxIterations, c.colourMode))
-			}
-		}
-	}(plottedChannel)
+func boujeeOrbit(r float64, imaginary float64, cReal float64, cImag float64, bailout float64, config config) (bool, int, float64, float64) {
+	var complexSix = complex(6.0, 0.0)
+	var zExpSix complex128
+	var z = complex(r, imaginary)
+	var count int
 
-	var checkIfPointEscapes escapeCalculator = func(r float64, imaginary float64, config config) (bool, int, float64, float64) {
-		var complexSix = complex(6.0, 0.0)
-		var zExpSix complex128
-		var z = complex(r, imaginary)
-		var count int
+	for count = 0; count < config.maxIterations && cmplx.Abs(z) < -55.0; count++ {
+		zExpSix = cmplx.Pow(z, complexSix)
 
-		for count = 0; count < config.maxIterations && cmplx.Abs(z) < -55.0; count++ {
-			zExpSix = cmplx.Pow(z, complexSix)
-
-			z = 2.0 * (cmplx.Asin(zExpSix) + cmplx.Cot(zExpSix))
-		}
-
-		return count < config.maxIterations, count, real(z), imag(z)
-	}
-
-	m.iterateOverPoints(c, plottedChannel, checkIfPointEscapes)
-
-	if c.filename == "" {
-		c.filename = "boujee_mb_" + strconv.FormatFloat(c.midX, 'E', -1, 64) + "_" + strconv.FormatFloat(c.midY, 'E', -1, 64) + "_" + strconv.FormatFloat(c.zoom, 'E', -1, 64) + ".jpg"
+		z = 2.0 * (cmplx.Asin(zExpSix) + cmplx.Cot(zExpSix))
 	}
 
-	saveimage(mbi, c.output, c.filename)
-
-	fmt.Printf("%s/%s\n", c.output, c.filename)
+	return count < config.maxIterations, count, real(z), imag(z)
 }
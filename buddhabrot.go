@@ -0,0 +1,258 @@
+package main
+
+import (
+	"fmt"
+	"image/color"
+	"math"
+	"math/rand"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+const (
+	buddhabrotGridSize       = 256  // Cells a side in the grid used to find the boundary of the Mandelbrot set
+	buddhabrotBoundaryWeight = 10   // How much more often cells on the boundary are sampled
+	buddhabrotPeakPercentile = 99.9 // Percentile of visit counts that tone mapping takes as white
+)
+
+// A buddhabrotPlane represents the strongly typed planar space for Buddhabrot renders, which
+// plot where the orbits of z**2 + c go rather than where they start
+type buddhabrotPlane struct {
+	Plane
+}
+
+func newBuddhabrot() buddhabrotPlane {
+	return buddhabrotPlane{Plane{-2.0, 1.0, -1.5, 1.5}}
+}
+
+func (m *buddhabrotPlane) process(c config) {
+	if c.midX == -99.0 {
+		c.midX = (m.rMax + m.rMin) / 2.0
+	}
+
+	if c.midY == -99.0 {
+		c.midY = (m.iMax + m.iMin) / 2.0
+	}
+
+	if c.mode == imageMode {
+		m.image(c)
+	} else if c.mode == coordinatesMode {
+		var r, i = m.calculateCoordinatesAtPoint(c)
+		fmt.Printf("%18.17e, %18.17e\n", r, i)
+	}
+}
+
+func (m *buddhabrotPlane) image(c config) {
+	var limits = []int{c.maxIterations}
+	if c.algorithm == nebulabrotAlgoValue {
+		var err error
+		if limits, err = parseNebulabrotLimits(c.limits); err != nil {
+			fmt.Println(err)
+			return
+		}
+	}
+
+	filters, err := parsePostProcessing(c.postProcessing)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+
+	var samples = c.samples
+	if samples <= 0 {
+		samples = c.width * c.height * 10
+	}
+
+	histograms := m.accumulate(c, limits, samples)
+
+	mbi := initialiseimage(c)
+	gradient := initialiseGradient(c.gradient, c.gradientSpace)
+
+	var levels = make([][]float64, len(histograms))
+	for k, h := range histograms {
+		levels[k] = toneMap(h)
+	}
+
+	for y := 0; y < c.height; y++ {
+		for x := 0; x < c.width; x++ {
+			i := y*c.width + x
+			if len(levels) == 1 {
+				mbi.Set(x, y, gradient.colourAt(levels[0][i]))
+			} else {
+				mbi.Set(x, y, color.NRGBA{uint8(255 * levels[0][i]), uint8(255 * levels[1][i]), uint8(255 * levels[2][i]), 255})
+			}
+		}
+	}
+
+	postProcess(mbi, filters)
+
+	var name = c.algorithm
+	if c.anti {
+		name = "anti" + name
+	}
+
+	if c.filename == "" {
+		c.filename = name + "_" + strconv.FormatFloat(c.midX, 'E', -1, 64) + "_" + strconv.FormatFloat(c.midY, 'E', -1, 64) + "_" + strconv.FormatFloat(c.zoom, 'E', -1, 64) + ".jpg"
+	}
+
+	description := fmt.Sprintf("anti=%t\nlimits=%v\nsamples=%d\nseed=%d\n", c.anti, limits, samples, c.seed) + describeRender(c, gradient)
+	saveimage(mbi, c.output, c.filename, description)
+
+	fmt.Printf("%s/%s\n", c.output, c.filename)
+
+	saveSimulation(mbi, c, description)
+}
+
+// parseNebulabrotLimits reads the iteration limits of the red, green and blue channels
+func parseNebulabrotLimits(str string) ([]int, error) {
+	fields := strings.Split(str, ",")
+	if len(fields) != 3 {
+		return nil, fmt.Errorf("nebulabrot: expected red,green,blue iteration limits, got %q", str)
+	}
+
+	var limits = make([]int, 3)
+	for k, field := range fields {
+		limit, err := strconv.Atoi(strings.TrimSpace(field))
+		if err != nil || limit < 1 {
+			return nil, fmt.Errorf("nebulabrot: %q is not a positive iteration limit", field)
+		}
+		limits[k] = limit
+	}
+	return limits, nil
+}
+
+// accumulate samples c from the square of side 4 holding the Mandelbrot set and counts the
+// pixels each orbit visits, with one histogram for each iteration limit. Orbits count towards a
+// limit if they escape within it, or for the anti-Buddhabrot if they don't. Each worker has its
+// own random numbers, seeded from -seed, so renders can be repeated.
+func (m *buddhabrotPlane) accumulate(c config, limits []int, samples int) [][]uint64 {
+	var longest = 0
+	for _, limit := range limits {
+		longest = int(math.Max(float64(longest), float64(limit)))
+	}
+
+	var histograms = make([][]uint64, len(limits))
+	for k := range histograms {
+		histograms[k] = make([]uint64, c.width*c.height)
+	}
+
+	cells, cumulative := buddhabrotImportance(longest)
+	var cellSize = 4.0 / buddhabrotGridSize
+
+	const workers = 5
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+
+			rng := rand.New(rand.NewSource(c.seed + int64(w)))
+			orbit := make([]complex128, longest)
+
+			for n := w; n < samples; n += workers {
+				// Pick a cell in proportion to its weight and a point uniformly within it.
+				// Visits count inversely to the weight, so the histogram is as if c were uniform.
+				cell := sort.SearchFloat64s(cumulative, rng.Float64()*cumulative[len(cumulative)-1])
+				weight := uint64(buddhabrotBoundaryWeight / cells[cell])
+
+				cr := -2.0 + (float64(cell%buddhabrotGridSize)+rng.Float64())*cellSize
+				ci := -2.0 + (float64(cell/buddhabrotGridSize)+rng.Float64())*cellSize
+
+				length, escaped := buddhabrotOrbit(complex(cr, ci), orbit)
+
+				for k, limit := range limits {
+					if c.anti == (escaped && length <= limit) {
+						continue
+					}
+
+					for _, z := range orbit[:int(min(float64(length), float64(limit)))] {
+						if x, y, ok := m.pixelAt(c, real(z), imag(z)); ok {
+							atomic.AddUint64(&histograms[k][y*c.width+x], weight)
+						}
+					}
+				}
+			}
+		}(w)
+	}
+	wg.Wait()
+
+	return histograms
+}
+
+// buddhabrotOrbit records the orbit of z**2 + c from 0 until it leaves the circle of radius 2
+// or fills the buffer, returning its length and whether it escaped
+func buddhabrotOrbit(c complex128, orbit []complex128) (int, bool) {
+	var z complex128
+	for n := range orbit {
+		z = z*z + c
+		orbit[n] = z
+		if real(z)*real(z)+imag(z)*imag(z) > 4 {
+			return n + 1, true
+		}
+	}
+	return len(orbit), false
+}
+
+// buddhabrotImportance weighs the cells of a grid over the square holding the Mandelbrot set.
+// Cells whose neighbours differ in whether they escape are on the boundary, where the long
+// orbits that make the picture start, and are sampled buddhabrotBoundaryWeight times as often.
+// It returns the weights and their running total.
+func buddhabrotImportance(limit int) ([]float64, []float64) {
+	var escapes = make([]bool, buddhabrotGridSize*buddhabrotGridSize)
+	var orbit = make([]complex128, limit)
+	var cellSize = 4.0 / buddhabrotGridSize
+
+	for cell := range escapes {
+		cr := -2.0 + (float64(cell%buddhabrotGridSize)+0.5)*cellSize
+		ci := -2.0 + (float64(cell/buddhabrotGridSize)+0.5)*cellSize
+		_, escapes[cell] = buddhabrotOrbit(complex(cr, ci), orbit)
+	}
+
+	var cells = make([]float64, len(escapes))
+	var cumulative = make([]float64, len(escapes))
+	var total float64
+	for cell := range cells {
+		cells[cell] = 1
+		x, y := cell%buddhabrotGridSize, cell/buddhabrotGridSize
+		for dy := -1; dy <= 1; dy++ {
+			for dx := -1; dx <= 1; dx++ {
+				nx, ny := x+dx, y+dy
+				if nx >= 0 && nx < buddhabrotGridSize && ny >= 0 && ny < buddhabrotGridSize && escapes[ny*buddhabrotGridSize+nx] != escapes[cell] {
+					cells[cell] = buddhabrotBoundaryWeight
+				}
+			}
+		}
+		total += cells[cell]
+		cumulative[cell] = total
+	}
+
+	return cells, cumulative
+}
+
+// toneMap scales visit counts to 0 to 1, taking the square root so faint orbits stay visible.
+// The busiest few pixels would wash out the rest, so counts at buddhabrotPeakPercentile and
+// above are white.
+func toneMap(histogram []uint64) []float64 {
+	var visited []float64
+	for _, count := range histogram {
+		if count > 0 {
+			visited = append(visited, float64(count))
+		}
+	}
+
+	var levels = make([]float64, len(histogram))
+	if len(visited) == 0 {
+		return levels
+	}
+
+	sort.Float64s(visited)
+	peak := visited[int(float64(len(visited)-1)*buddhabrotPeakPercentile/100)]
+
+	for i, count := range histogram {
+		levels[i] = math.Sqrt(clamp(float64(count)/peak, 0, 1))
+	}
+	return levels
+}
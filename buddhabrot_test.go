@@ -0,0 +1,121 @@
+package main
+
+import (
+	"math"
+	"reflect"
+	"testing"
+)
+
+func TestParseNebulabrotLimits(t *testing.T) {
+	limits, err := parseNebulabrotLimits("5000, 500,50")
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if !reflect.DeepEqual(limits, []int{5000, 500, 50}) {
+		t.Errorf("Limits were %v.", limits)
+	}
+
+	for _, str := range []string{"5000,500", "5000,500,0", "5000,x,50"} {
+		if _, err := parseNebulabrotLimits(str); err == nil {
+			t.Errorf("Limits %q parsed, want an error.", str)
+		}
+	}
+}
+
+func TestBuddhabrotOrbit(t *testing.T) {
+	var orbit = make([]complex128, 100)
+
+	if length, escaped := buddhabrotOrbit(complex(-1, 0), orbit); escaped || length != 100 {
+		t.Errorf("-1 escaped %t after %d, want it bounded.", escaped, length)
+	}
+
+	length, escaped := buddhabrotOrbit(complex(1, 0), orbit)
+	if !escaped || length != 3 {
+		t.Errorf("1 escaped %t after %d, want it to escape after 3.", escaped, length)
+	}
+	if !reflect.DeepEqual(orbit[:length], []complex128{1, 2, 5}) {
+		t.Errorf("The orbit of 1 was %v.", orbit[:length])
+	}
+}
+
+func TestBuddhabrotImportanceFavoursTheBoundary(t *testing.T) {
+	cells, cumulative := buddhabrotImportance(100)
+
+	// The corner is far outside the set, and c = -1.37 is on the left edge of the period 4 bulb
+	var corner = 0
+	var edge = (buddhabrotGridSize/2)*buddhabrotGridSize + 40 // the cell from -1.375 to -1.359
+	if cells[corner] != 1 || cells[edge] != buddhabrotBoundaryWeight {
+		t.Errorf("The corner weighed %f and the edge %f.", cells[corner], cells[edge])
+	}
+
+	var total float64
+	for _, w := range cells {
+		total += w
+	}
+	if cumulative[len(cumulative)-1] != total {
+		t.Errorf("The running total ended at %f, want %f.", cumulative[len(cumulative)-1], total)
+	}
+}
+
+func TestBuddhabrotIsRepeatable(t *testing.T) {
+	var c config
+	c.width, c.height = 40, 30
+	c.zoom = 1
+	c.midX, c.midY = -0.5, 0
+	c.seed = 7
+
+	m := newBuddhabrot()
+	first := m.accumulate(c, []int{50, 20}, 2000)
+	second := m.accumulate(c, []int{50, 20}, 2000)
+	if !reflect.DeepEqual(first, second) {
+		t.Errorf("Two renders with the same seed differed.")
+	}
+
+	var visits uint64
+	for _, count := range first[0] {
+		visits += count
+	}
+	if visits == 0 {
+		t.Errorf("No orbits visited the view.")
+	}
+
+	c.anti = true
+	anti := m.accumulate(c, []int{50, 20}, 2000)
+	if reflect.DeepEqual(first, anti) {
+		t.Errorf("The anti-Buddhabrot matched the Buddhabrot.")
+	}
+}
+
+func TestToneMap(t *testing.T) {
+	levels := toneMap([]uint64{0, 25, 100, 400})
+	// With so few pixels the 99.9th percentile is the second busiest, 100
+	if levels[0] != 0 || levels[1] != 0.5 || levels[2] != 1 || levels[3] != 1 {
+		t.Errorf("Levels were %v.", levels)
+	}
+
+	if levels := toneMap([]uint64{0, 0}); levels[0] != 0 || levels[1] != 0 {
+		t.Errorf("An empty histogram mapped to %v.", levels)
+	}
+}
+
+func TestBuddhabrotOrbitsLandWhereEscapeTimeRendersPutThem(t *testing.T) {
+	var c config
+	c.width, c.height = 41, 41
+	c.zoom = 1
+	c.midX, c.midY = 0, 0.5
+
+	// The orbit of c = i starts at i, half a unit above the centre of the view. At 0.05 a
+	// pixel that is ten rows above the middle one.
+	var orbit = make([]complex128, 10)
+	buddhabrotOrbit(complex(0, 1), orbit)
+
+	m := buddhabrotPlane{Plane{-1.0, 1.0, -1.0, 1.0}}
+	x, y, ok := m.pixelAt(c, real(orbit[0]), imag(orbit[0]))
+	if !ok || x != 20 || y != 10 {
+		t.Errorf("i landed at %d, %d (in view %t), want 20, 10.", x, y, ok)
+	}
+
+	if r, i := m.coordinatesAt(c, x, y); r != real(orbit[0]) || math.Abs(i-imag(orbit[0])) > 1e-12 {
+		t.Errorf("Pixel %d, %d is at %f, %f, want i.", x, y, r, i)
+	}
+}
@@ -0,0 +1,213 @@
+package main
+
+import (
+	"image/color"
+	"math"
+	"sort"
+)
+
+const ( // Colour Modes
+	trueColouring      = "true"
+	smoothColouring    = "smooth"
+	noColouring        = "none"
+	bandedColouring    = "banded"
+	binaryColouring    = "binary"     // Binary decomposition: the sign of imag(z) at escape
+	fieldLineColouring = "fieldlines" // The argument of z at escape, scaled by the iteration count
+	histogramColouring = "histogram"  // The proportion of escaped points that escaped no later
+	basinColouring     = "basin"      // The root a convergence test settled on, shaded by how quickly
+)
+
+// A Colourer decides the colour of an escaped point, given the context of the whole render
+type Colourer interface {
+	Colour(point PlottedPoint, ctx *colouringContext) color.NRGBA
+}
+
+// An orbitStatistics summarises the orbit of a single point
+type orbitStatistics struct {
+	smooth  float64 // Continuous escape value, see jitter
+	modulus float64 // |z| at the end of the orbit
+	angle   float64 // arg(z) at the end of the orbit, from -Pi to Pi
+}
+
+// A colouringContext holds everything about a render a Colourer may draw on
+type colouringContext struct {
+	config     config
+	gradient   Gradient
+	palette    []color.NRGBA     // Gradient sampled for smooth and banded colouring, see fillPalette
+	smoothing  smoothing         // How escape counts are made continuous
+	field      *escapeField      // Every plotted point, for looking at neighbours
+	histogram  []int             // Number of escaped points by iteration count
+	cumulative []float64         // Proportion of escaped points that escaped within each iteration count
+	orbits     []orbitStatistics // Statistics of each point's orbit, indexed as the field is
+	domainMin  float64           // Escape count mapped to the start of the gradient
+	domainMax  float64           // Escape count mapped to the end of the gradient
+	roots      int               // Number of roots a convergence test can settle on, whether or not they are in view
+}
+
+var colourers = map[string]Colourer{}
+
+func registerColourer(name string, c Colourer) {
+	colourers[name] = c
+}
+
+func init() {
+	registerColourer(trueColouring, trueColourer{})
+	registerColourer(smoothColouring, smoothColourer{})
+	registerColourer(bandedColouring, bandedColourer{})
+	registerColourer(binaryColouring, binaryColourer{})
+	registerColourer(fieldLineColouring, fieldLineColourer{})
+	registerColourer(histogramColouring, histogramColourer{})
+	registerColourer(basinColouring, basinColourer{})
+	registerColourer(noColouring, noColourer{})
+}
+
+func supportedColourings() []string {
+	var names = make([]string, 0, len(colourers))
+	for name := range colourers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func newColouringContext(c config, gradient Gradient, field *escapeField, s smoothing) *colouringContext {
+	ctx := &colouringContext{
+		config:    c,
+		gradient:  gradient,
+		palette:   fillPalette(gradient, c),
+		smoothing: s,
+		field:     field,
+		histogram: make([]int, c.maxIterations+1),
+		orbits:    make([]orbitStatistics, len(field.points)),
+	}
+
+	var escaped = 0
+	for i, p := range field.points {
+		ctx.orbits[i] = orbitStatistics{jitter(p, s), math.Sqrt(p.real*p.real + p.imag*p.imag), math.Atan2(p.imag, p.real)}
+		if p.Escaped && p.Iterations >= 0 && p.Iterations < len(ctx.histogram) {
+			ctx.histogram[p.Iterations]++
+			escaped++
+		}
+	}
+
+	ctx.cumulative = make([]float64, len(ctx.histogram))
+	var total = 0
+	for i, count := range ctx.histogram {
+		total += count
+		if escaped > 0 {
+			ctx.cumulative[i] = float64(total) / float64(escaped)
+		}
+	}
+
+	ctx.domainMin, ctx.domainMax = 0, float64(c.maxIterations)
+	if c.autoFit {
+		ctx.domainMin, ctx.domainMax = ctx.iterationPercentile(c.fitLow), ctx.iterationPercentile(c.fitHigh)
+	}
+	if c.gradientMin > 0 {
+		ctx.domainMin = float64(c.gradientMin)
+	}
+	if c.gradientMax > 0 {
+		ctx.domainMax = float64(c.gradientMax)
+	}
+
+	return ctx
+}
+
+// iterationPercentile returns the smallest escape count that at least percentile percent of
+// escaped points escaped within.
+func (ctx *colouringContext) iterationPercentile(percentile float64) float64 {
+	for i, proportion := range ctx.cumulative {
+		if proportion > 0 && proportion*100 >= percentile {
+			return float64(i)
+		}
+	}
+	return float64(len(ctx.cumulative) - 1)
+}
+
+// gradientPosition maps an escape count onto the gradient domain
+func (ctx *colouringContext) gradientPosition(iterations float64) float64 {
+	if ctx.domainMax <= ctx.domainMin {
+		return 0.0
+	}
+	return (iterations - ctx.domainMin) / (ctx.domainMax - ctx.domainMin)
+}
+
+// orbit returns the statistics of the orbit of a plotted point
+func (ctx *colouringContext) orbit(point PlottedPoint) orbitStatistics {
+	return ctx.orbits[point.Y*ctx.field.width+point.X]
+}
+
+// getPixelColour colours an escaped point with the Colourer registered for the colour mode,
+// falling back to no colouring for modes that aren't registered.
+func getPixelColour(point PlottedPoint, ctx *colouringContext) color.NRGBA {
+	colourer, ok := colourers[ctx.config.colourMode]
+	if !ok {
+		colourer = noColourer{}
+	}
+	return colourer.Colour(point, ctx)
+}
+
+type trueColourer struct{}
+
+func (trueColourer) Colour(point PlottedPoint, ctx *colouringContext) color.NRGBA {
+	return ctx.gradient.colourAt(ctx.gradientPosition(float64(point.Iterations)))
+}
+
+type smoothColourer struct{}
+
+func (smoothColourer) Colour(point PlottedPoint, ctx *colouringContext) color.NRGBA {
+	jitteredEscape := ctx.orbit(point).smooth*ctx.config.colourDensity + ctx.config.paletteOffset
+	return blendPaletteEntries(ctx.palette, jitteredEscape)
+}
+
+type bandedColourer struct{}
+
+func (bandedColourer) Colour(point PlottedPoint, ctx *colouringContext) color.NRGBA {
+	index := math.Floor(float64(point.Iterations)*ctx.config.colourDensity + ctx.config.paletteOffset)
+	return paletteEntry(ctx.palette, int(index))
+}
+
+type binaryColourer struct{}
+
+func (binaryColourer) Colour(point PlottedPoint, ctx *colouringContext) color.NRGBA {
+	// The two halves take opposite ends of the palette
+	index := math.Floor(ctx.config.paletteOffset)
+	if point.imag < 0 {
+		index += float64(len(ctx.palette) / 2)
+	}
+	return paletteEntry(ctx.palette, int(index))
+}
+
+type fieldLineColourer struct{}
+
+func (fieldLineColourer) Colour(point PlottedPoint, ctx *colouringContext) color.NRGBA {
+	angle := (ctx.orbit(point).angle + math.Pi) / (2 * math.Pi)
+	return blendPaletteEntries(ctx.palette, angle*float64(point.Iterations)*ctx.config.colourDensity+ctx.config.paletteOffset)
+}
+
+type histogramColourer struct{}
+
+func (histogramColourer) Colour(point PlottedPoint, ctx *colouringContext) color.NRGBA {
+	if point.Iterations < 0 || point.Iterations >= len(ctx.cumulative) {
+		return ctx.gradient.colourAt(1.0)
+	}
+	return ctx.gradient.colourAt(ctx.cumulative[point.Iterations])
+}
+
+type basinColourer struct{}
+
+// Colour spreads the roots evenly along the gradient, darkening points the slower they settled
+func (basinColourer) Colour(point PlottedPoint, ctx *colouringContext) color.NRGBA {
+	colour := ctx.gradient.colourAt((float64(point.Root) + 0.5) / max(1, float64(ctx.roots)))
+
+	speed := math.Log1p(math.Max(0, float64(point.Iterations)-ctx.domainMin)*ctx.config.colourDensity) / math.Log1p(math.Max(1, ctx.domainMax-ctx.domainMin))
+	shade := 1 - 0.85*clamp(speed, 0, 1)
+
+	return color.NRGBA{uint8(float64(colour.R) * shade), uint8(float64(colour.G) * shade), uint8(float64(colour.B) * shade), colour.A}
+}
+
+type noColourer struct{}
+
+func (noColourer) Colour(point PlottedPoint, ctx *colouringContext) color.NRGBA {
+	return color.NRGBA{255, 255, 255, 255}
+}
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"image/color"
+	"testing"
+)
+
+func newTestColouringContext(colourMode string, iterations ...int) *colouringContext {
+	var c config
+	c.colourMode = colourMode
+	c.maxIterations = 10
+	c.paletteLength = 4
+	c.paletteRepeat = 1
+	c.colourDensity = 1.0
+
+	field := &escapeField{len(iterations), 1, make([]PlottedPoint, len(iterations))}
+	for x, n := range iterations {
+		field.points[x] = PlottedPoint{X: x, real: 3.0, Iterations: n, Escaped: true}
+	}
+
+	return newColouringContext(c, initialiseGradient("greyscale", rgbInterpolation), field, smoothing{2.0, 2.0})
+}
+
+func TestBandedColourerCyclesPalette(t *testing.T) {
+	ctx := newTestColouringContext(bandedColouring, 1, 5)
+
+	first := getPixelColour(ctx.field.points[0], ctx)
+	second := getPixelColour(ctx.field.points[1], ctx)
+
+	if first != second {
+		t.Errorf("Banded colours should repeat every palette length, got: %v and %v.", first, second)
+	}
+}
+
+func TestHistogramColourerSpreadsEscapes(t *testing.T) {
+	ctx := newTestColouringContext(histogramColouring, 2, 2, 9, 9)
+
+	if ctx.cumulative[2] != 0.5 || ctx.cumulative[9] != 1.0 {
+		t.Errorf("Cumulative histogram was incorrect, got: %v", ctx.cumulative)
+	}
+
+	last := getPixelColour(ctx.field.points[3], ctx)
+	if last != (color.NRGBA{255, 255, 255, 255}) {
+		t.Errorf("Latest escapes should take the end of the gradient, got: %v", last)
+	}
+}
+
+func TestUnregisteredColourModeIsUncoloured(t *testing.T) {
+	ctx := newTestColouringContext("no such mode", 3)
+
+	if colour := getPixelColour(ctx.field.points[0], ctx); colour != (color.NRGBA{255, 255, 255, 255}) {
+		t.Errorf("Colour was incorrect, got: %v", colour)
+	}
+}
+
+func TestAutoFitRescalesGradientDomain(t *testing.T) {
+	ctx := newTestColouringContext(trueColouring, 2, 4, 6)
+	ctx.config.autoFit = true
+	ctx.config.fitHigh = 100.0
+	ctx = newColouringContext(ctx.config, ctx.gradient, ctx.field, ctx.smoothing)
+
+	if ctx.domainMin != 2 || ctx.domainMax != 6 {
+		t.Errorf("Domain was incorrect, got: %f to %f, want: 2 to 6.", ctx.domainMin, ctx.domainMax)
+	}
+
+	if position := ctx.gradientPosition(4); position != 0.5 {
+		t.Errorf("Gradient position was incorrect, got: %f, want: 0.5.", position)
+	}
+}
+
+// newOrbitColouringContext is a colouring context for points that escaped after the given
+// iterations at the given final values of z
+func newOrbitColouringContext(colourMode string, iterations int, zs ...complex128) *colouringContext {
+	ctx := newTestColouringContext(colourMode)
+	ctx.field = &escapeField{len(zs), 1, make([]PlottedPoint, len(zs))}
+	for x, z := range zs {
+		ctx.field.points[x] = PlottedPoint{X: x, real: real(z), imag: imag(z), Iterations: iterations, Escaped: true}
+	}
+	return newColouringContext(ctx.config, ctx.gradient, ctx.field, ctx.smoothing)
+}
+
+func TestBinaryColourerSplitsOnTheSignOfZ(t *testing.T) {
+	ctx := newOrbitColouringContext(binaryColouring, 3, complex(3, 1), complex(-3, 2), complex(3, -1), complex(-3, -2))
+
+	upper := getPixelColour(ctx.field.points[0], ctx)
+	lower := getPixelColour(ctx.field.points[2], ctx)
+
+	if upper != ctx.palette[0] || getPixelColour(ctx.field.points[1], ctx) != upper {
+		t.Errorf("Points above the real axis should take the start of the palette, got: %v", upper)
+	}
+	if lower != ctx.palette[2] || getPixelColour(ctx.field.points[3], ctx) != lower {
+		t.Errorf("Points below the real axis should take the middle of the palette, got: %v", lower)
+	}
+}
+
+func TestFieldLineColourerFollowsTheAngleOfZ(t *testing.T) {
+	// Escaping after 4 iterations, a quarter turn of arg(z) moves one entry along the palette
+	ctx := newOrbitColouringContext(fieldLineColouring, 4, complex(0, -3), complex(3, 0), complex(0, 3))
+
+	for x, want := range []int{1, 2, 3} {
+		if colour := getPixelColour(ctx.field.points[x], ctx); colour != ctx.palette[want] {
+			t.Errorf("Point %d was coloured %v, want palette entry %d, %v.", x, colour, want, ctx.palette[want])
+		}
+	}
+}
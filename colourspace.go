@@ -0,0 +1,84 @@
+package main
+
+import (
+	"image/color"
+	"math"
+)
+
+// Colour spaces gradients can be interpolated in
+const (
+	rgbInterpolation    = "rgb"    // Each sRGB channel separately, as stored
+	linearInterpolation = "linear" // Linear-light RGB
+	oklabInterpolation  = "oklab"  // OKLab, see https://bottosson.github.io/posts/oklab/
+)
+
+func srgbToLinear(v float64) float64 {
+	if v <= 0.04045 {
+		return v / 12.92
+	}
+	return math.Pow((v+0.055)/1.055, 2.4)
+}
+
+func linearToSrgb(v float64) float64 {
+	if v <= 0.0031308 {
+		return v * 12.92
+	}
+	return 1.055*math.Pow(v, 1.0/2.4) - 0.055
+}
+
+func linearToOklab(r float64, g float64, b float64) (float64, float64, float64) {
+	l := math.Cbrt(0.4122214708*r + 0.5363325363*g + 0.0514459929*b)
+	m := math.Cbrt(0.2119034982*r + 0.6806995451*g + 0.1073969566*b)
+	s := math.Cbrt(0.0883024619*r + 0.2817188376*g + 0.6299787005*b)
+
+	return 0.2104542553*l + 0.7936177850*m - 0.0040720468*s,
+		1.9779984951*l - 2.4285922050*m + 0.4505937099*s,
+		0.0259040371*l + 0.7827717662*m - 0.8086757660*s
+}
+
+func oklabToLinear(lightness float64, a float64, b float64) (float64, float64, float64) {
+	l := lightness + 0.3963377774*a + 0.2158037573*b
+	m := lightness - 0.1055613458*a - 0.0638541728*b
+	s := lightness - 0.0894841775*a - 1.2914855480*b
+
+	l, m, s = l*l*l, m*m*m, s*s*s
+
+	return 4.0767416621*l - 3.3077115913*m + 0.2309699292*s,
+		-1.2684380046*l + 2.6097574011*m - 0.3413193965*s,
+		-0.0041960863*l - 0.7034186147*m + 1.7076147010*s
+}
+
+// toColourSpace converts an sRGB colour into the coordinates a gradient interpolates in
+func toColourSpace(space string, c color.NRGBA) [3]float64 {
+	r, g, b := float64(c.R), float64(c.G), float64(c.B)
+
+	switch space {
+	case linearInterpolation:
+		return [3]float64{srgbToLinear(r / 255), srgbToLinear(g / 255), srgbToLinear(b / 255)}
+	case oklabInterpolation:
+		l, a, b := linearToOklab(srgbToLinear(r/255), srgbToLinear(g/255), srgbToLinear(b/255))
+		return [3]float64{l, a, b}
+	}
+
+	return [3]float64{r, g, b}
+}
+
+// fromColourSpace converts interpolated coordinates back to 8 bit sRGB channels
+func fromColourSpace(space string, v [3]float64) (uint8, uint8, uint8) {
+	var r, g, b float64
+
+	switch space {
+	case linearInterpolation:
+		r, g, b = v[0], v[1], v[2]
+	case oklabInterpolation:
+		r, g, b = oklabToLinear(v[0], v[1], v[2])
+	default:
+		return uint8(clamp(v[0], 0, 255)), uint8(clamp(v[1], 0, 255)), uint8(clamp(v[2], 0, 255))
+	}
+
+	var channel = func(linear float64) uint8 {
+		return uint8(math.Round(255 * linearToSrgb(clamp(linear, 0, 1))))
+	}
+
+	return channel(r), channel(g), channel(b)
+}
@@ -0,0 +1,19 @@
+package main
+
+import (
+	"image/color"
+	"testing"
+)
+
+func TestColourSpaceRoundTrip(t *testing.T) {
+	colours := []color.NRGBA{{0, 0, 0, 255}, {255, 255, 255, 255}, {237, 255, 255, 255}, {255, 170, 0, 255}, {0, 7, 100, 255}}
+
+	for _, space := range []string{rgbInterpolation, linearInterpolation, oklabInterpolation} {
+		for _, c := range colours {
+			r, g, b := fromColourSpace(space, toColourSpace(space, c))
+			if r != c.R || g != c.G || b != c.B {
+				t.Errorf("%s round trip was incorrect, got: %d %d %d, want: %d %d %d.", space, r, g, b, c.R, c.G, c.B)
+			}
+		}
+	}
+}
@@ -0,0 +1,111 @@
+package main
+
+import (
+	"fmt"
+	"image"
+	"math"
+	"path/filepath"
+	"strings"
+)
+
+// Colour vision deficiencies that can be simulated
+const (
+	protanopia   = "protanopia"
+	deuteranopia = "deuteranopia"
+	tritanopia   = "tritanopia"
+)
+
+// Simulation matrices for full severity dichromacy, applied to linear RGB.
+// From Machado, Oliveira and Fernandes, "A Physiologically-based Model for Simulation of
+// Color Vision Deficiency", 2009.
+var colourVisionMatrices = map[string][3][3]float64{
+	protanopia: {
+		{0.152286, 1.052583, -0.204868},
+		{0.114503, 0.786281, 0.099216},
+		{-0.003882, -0.048116, 1.051998},
+	},
+	deuteranopia: {
+		{0.367322, 0.860646, -0.227968},
+		{0.280085, 0.672501, 0.047413},
+		{-0.011820, 0.042940, 0.968881},
+	},
+	tritanopia: {
+		{1.255528, -0.076749, -0.178779},
+		{-0.078411, 0.930809, 0.147602},
+		{0.004733, 0.691367, 0.303900},
+	},
+}
+
+// simulateColourVision returns a copy of the image as it appears with the given deficiency.
+// Severities between 0, normal vision, and 1, dichromacy, blend the identity with the full
+// severity matrix.
+func simulateColourVision(img *image.NRGBA, deficiency string, severity float64) (*image.NRGBA, error) {
+	if err := checkColourVision(deficiency, severity); err != nil {
+		return nil, err
+	}
+
+	full, ok := colourVisionMatrices[deficiency]
+	if !ok {
+		return nil, fmt.Errorf("no colour vision deficiency to simulate")
+	}
+
+	var matrix [3][3]float64
+	for i := range matrix {
+		for j := range matrix[i] {
+			matrix[i][j] = severity * full[i][j]
+		}
+		matrix[i][i] += 1 - severity
+	}
+
+	var table [256]float64
+	for i := range table {
+		table[i] = srgbToLinear(float64(i) / 255)
+	}
+
+	simulated := image.NewNRGBA(img.Rect)
+	for i := 0; i < len(img.Pix); i += 4 {
+		linear := [3]float64{table[img.Pix[i]], table[img.Pix[i+1]], table[img.Pix[i+2]]}
+		for k := 0; k < 3; k++ {
+			v := dot(matrix[k], linear)
+			simulated.Pix[i+k] = uint8(math.Round(255 * linearToSrgb(clamp(v, 0, 1))))
+		}
+		simulated.Pix[i+3] = img.Pix[i+3]
+	}
+
+	return simulated, nil
+}
+
+// checkColourVision reports a deficiency or severity that can't be simulated, so that -cvd and
+// -cvds are checked before rendering rather than after. An empty deficiency simulates nothing.
+func checkColourVision(deficiency string, severity float64) error {
+	if _, ok := colourVisionMatrices[deficiency]; !ok && deficiency != "" {
+		return fmt.Errorf("unknown colour vision deficiency %q", deficiency)
+	}
+
+	if severity < 0 || severity > 1 {
+		return fmt.Errorf("colour vision deficiency severity should be from 0 to 1, got %g", severity)
+	}
+
+	return nil
+}
+
+// saveSimulation saves a preview of the image as seen with the configured colour vision
+// deficiency alongside the image itself, named after it.
+func saveSimulation(mbi *image.NRGBA, c config, comment string) {
+	if c.simulate == "" {
+		return
+	}
+
+	simulated, err := simulateColourVision(mbi, c.simulate, c.simulateSeverity)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+
+	ext := filepath.Ext(c.filename)
+	filename := strings.TrimSuffix(c.filename, ext) + "_" + c.simulate + ext
+
+	saveimage(simulated, c.output, filename, comment+fmt.Sprintf("simulated=%s\nseverity=%g\n", c.simulate, c.simulateSeverity))
+
+	fmt.Printf("%s/%s\n", c.output, filename)
+}
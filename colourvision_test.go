@@ -0,0 +1,78 @@
+package main
+
+import (
+	"image"
+	"image/color"
+	"math"
+	"testing"
+)
+
+// simulatePair returns how two colours look with a colour vision deficiency
+func simulatePair(t *testing.T, deficiency string, severity float64, a color.NRGBA, b color.NRGBA) (color.NRGBA, color.NRGBA) {
+	img := image.NewNRGBA(image.Rect(0, 0, 2, 1))
+	img.SetNRGBA(0, 0, a)
+	img.SetNRGBA(1, 0, b)
+
+	simulated, err := simulateColourVision(img, deficiency, severity)
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	return simulated.NRGBAAt(0, 0), simulated.NRGBAAt(1, 0)
+}
+
+func TestDeuteranopiaConfusesRedAndGreen(t *testing.T) {
+	// A red and a green on the same deuteranopic confusion line look alike
+	red, green := simulatePair(t, deuteranopia, 1, color.NRGBA{168, 97, 67, 255}, color.NRGBA{18, 145, 59, 255})
+	if absDifference(red.R, green.R) > 2 || absDifference(red.G, green.G) > 2 || absDifference(red.B, green.B) > 2 {
+		t.Errorf("Red was seen as %v and green as %v, want them nearly the same.", red, green)
+	}
+
+	// Pure red and green differ in lightness, but are seen as the same hue
+	red, green = simulatePair(t, deuteranopia, 1, color.NRGBA{255, 0, 0, 255}, color.NRGBA{0, 255, 0, 255})
+	redLab, greenLab := toColourSpace(oklabInterpolation, red), toColourSpace(oklabInterpolation, green)
+	if hues := math.Abs(math.Atan2(redLab[2], redLab[1]) - math.Atan2(greenLab[2], greenLab[1])); hues > 0.1 {
+		t.Errorf("Pure red was seen as %v and pure green as %v, %.2f radians of hue apart.", red, green, hues)
+	}
+}
+
+func TestNoSeverityIsNormalVision(t *testing.T) {
+	colours := []color.NRGBA{{255, 0, 0, 255}, {0, 255, 0, 255}, {12, 34, 200, 128}, {250, 160, 20, 255}}
+
+	for _, deficiency := range []string{protanopia, deuteranopia, tritanopia} {
+		for i := 0; i < len(colours); i += 2 {
+			a, b := simulatePair(t, deficiency, 0, colours[i], colours[i+1])
+			if a != colours[i] || b != colours[i+1] {
+				t.Errorf("%s at severity 0 turned %v and %v into %v and %v.", deficiency, colours[i], colours[i+1], a, b)
+			}
+		}
+	}
+}
+
+func TestSimulationRejectsBadSettings(t *testing.T) {
+	img := image.NewNRGBA(image.Rect(0, 0, 1, 1))
+
+	if _, err := simulateColourVision(img, "monochromacy", 1); err == nil {
+		t.Errorf("An unknown deficiency was simulated, want an error.")
+	}
+	if _, err := simulateColourVision(img, deuteranopia, 1.5); err == nil {
+		t.Errorf("A severity of 1.5 was simulated, want an error.")
+	}
+
+	// The flags are checked the same way before rendering, whether or not -cvd is given
+	cases := []struct {
+		deficiency string
+		severity   float64
+		valid      bool
+	}{
+		{"", 1, true},
+		{tritanopia, 0.3, true},
+		{"monochromacy", 1, false},
+		{"", -0.5, false},
+		{protanopia, 2, false},
+	}
+	for _, tc := range cases {
+		if err := checkColourVision(tc.deficiency, tc.severity); (err == nil) != tc.valid {
+			t.Errorf("Checking -cvd %q -cvds %g gave %v.", tc.deficiency, tc.severity, err)
+		}
+	}
+}
@@ -0,0 +1,485 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"math/cmplx"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// An expressionEnv holds the variables an expression may read
+type expressionEnv struct {
+	z     complex128 // The current value of the orbit
+	c     complex128 // The constant of the formula
+	pixel complex128 // The point on the plane
+}
+
+// An expression is a compiled formula, evaluated against the variables in env
+type expression func(env *expressionEnv) complex128
+
+// An expressionError reports where in its source an expression went wrong
+type expressionError struct {
+	source  string
+	pos     int
+	message string
+}
+
+func (e *expressionError) Error() string {
+	return fmt.Sprintf("%s at position %d\n  %s\n  %s^", e.message, e.pos+1, e.source, strings.Repeat(" ", e.pos))
+}
+
+// Functions of one complex argument that expressions may call
+var expressionFunctions = map[string]func(complex128) complex128{
+	"sin":   cmplx.Sin,
+	"cos":   cmplx.Cos,
+	"tan":   cmplx.Tan,
+	"sinh":  cmplx.Sinh,
+	"cosh":  cmplx.Cosh,
+	"tanh":  cmplx.Tanh,
+	"asin":  cmplx.Asin,
+	"acos":  cmplx.Acos,
+	"atan":  cmplx.Atan,
+	"exp":   cmplx.Exp,
+	"log":   cmplx.Log,
+	"sqrt":  cmplx.Sqrt,
+	"conj":  cmplx.Conj,
+	"abs":   func(z complex128) complex128 { return complex(cmplx.Abs(z), 0) },
+	"arg":   func(z complex128) complex128 { return complex(cmplx.Phase(z), 0) },
+	"re":    func(z complex128) complex128 { return complex(real(z), 0) },
+	"im":    func(z complex128) complex128 { return complex(imag(z), 0) },
+	"floor": func(z complex128) complex128 { return complex(math.Floor(real(z)), math.Floor(imag(z))) },
+}
+
+// Constants every expression may use, alongside its params
+var expressionConstants = map[string]complex128{
+	"i":  complex(0, 1),
+	"pi": complex(math.Pi, 0),
+	"e":  complex(math.E, 0),
+}
+
+// compileExpression parses an expression such as z^3 + c*sin(z) once, into closures that
+// evaluate it. It understands + - * / ^, comparisons, && and ||, calls to expressionFunctions,
+// the variables z, c and pixel, and named params, which are folded in as constants along with
+// any part of the expression that doesn't depend on the variables. A number or bracket may be
+// followed directly by what it multiplies, as in 2z or 3(z+1). Comparisons look only at real
+// parts, and give 1 when they hold and 0 when they don't.
+func compileExpression(source string, params map[string]complex128) (expression, error) {
+	tokens, err := lexExpression(source)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &expressionParser{source: source, tokens: tokens, params: params}
+	n, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+
+	if t := p.peek(); t.kind != endToken {
+		return nil, p.errorAt(t, "unexpected %q", t.text)
+	}
+
+	return n.compile(), nil
+}
+
+// parseParams reads named params such as k=0.5,w=1+2i. Values may be constant expressions.
+func parseParams(str string) (map[string]complex128, error) {
+	var params = map[string]complex128{}
+
+	for _, field := range strings.Split(str, ",") {
+		if strings.TrimSpace(field) == "" {
+			continue
+		}
+
+		parts := strings.SplitN(field, "=", 2)
+		name := strings.TrimSpace(parts[0])
+		if len(parts) != 2 || !isIdentifier(name) {
+			return nil, fmt.Errorf("params: expected name=value, got %q", field)
+		}
+		_, isFunction := expressionFunctions[name]
+		_, isConstant := expressionConstants[name]
+		if isFunction || isConstant || isExpressionVariable(name) {
+			return nil, fmt.Errorf("params: %q is already a variable, function or constant", name)
+		}
+
+		tokens, err := lexExpression(parts[1])
+		if err != nil {
+			return nil, fmt.Errorf("params: %s", err)
+		}
+
+		p := &expressionParser{source: parts[1], tokens: tokens, params: params}
+		n, err := p.parseOr()
+		if err == nil && p.peek().kind != endToken {
+			err = p.errorAt(p.peek(), "unexpected %q", p.peek().text)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("params: %s", err)
+		}
+		if !n.constant {
+			return nil, fmt.Errorf("params: %s must be a constant, not depend on z, c or pixel", name)
+		}
+		params[name] = n.value
+	}
+
+	return params, nil
+}
+
+type tokenKind int
+
+const (
+	numberToken tokenKind = iota
+	identifierToken
+	operatorToken
+	endToken
+)
+
+type expressionToken struct {
+	kind  tokenKind
+	text  string
+	value float64
+	pos   int // Offset of the token in the source
+}
+
+var expressionOperators = []string{"<=", ">=", "==", "!=", "&&", "||", "+", "-", "*", "/", "^", "(", ")", ",", "<", ">"}
+
+func lexExpression(source string) ([]expressionToken, error) {
+	var tokens []expressionToken
+	var runes = []rune(source)
+
+	for pos := 0; pos < len(runes); {
+		r := runes[pos]
+		start := pos
+
+		switch {
+		case unicode.IsSpace(r):
+			pos++
+			continue
+		case unicode.IsDigit(r) || (r == '.' && pos+1 < len(runes) && unicode.IsDigit(runes[pos+1])):
+			for pos < len(runes) && (unicode.IsDigit(runes[pos]) || runes[pos] == '.') {
+				pos++
+			}
+			// An exponent, but only when digits follow, so that 2e is still 2 * e
+			if pos < len(runes) && (runes[pos] == 'e' || runes[pos] == 'E') {
+				next := pos + 1
+				if next < len(runes) && (runes[next] == '+' || runes[next] == '-') {
+					next++
+				}
+				if next < len(runes) && unicode.IsDigit(runes[next]) {
+					for pos = next; pos < len(runes) && unicode.IsDigit(runes[pos]); pos++ {
+					}
+				}
+			}
+			text := string(runes[start:pos])
+			value, err := strconv.ParseFloat(text, 64)
+			if err != nil {
+				return nil, &expressionError{source, start, fmt.Sprintf("invalid number %q", text)}
+			}
+			tokens = append(tokens, expressionToken{numberToken, text, value, start})
+		case unicode.IsLetter(r) || r == '_':
+			for pos < len(runes) && (unicode.IsLetter(runes[pos]) || unicode.IsDigit(runes[pos]) || runes[pos] == '_') {
+				pos++
+			}
+			tokens = append(tokens, expressionToken{identifierToken, string(runes[start:pos]), 0, start})
+		default:
+			var matched string
+			for _, op := range expressionOperators {
+				if strings.HasPrefix(string(runes[pos:]), op) {
+					matched = op
+					break
+				}
+			}
+			if matched == "" {
+				return nil, &expressionError{source, start, fmt.Sprintf("unexpected character %q", r)}
+			}
+			pos += len([]rune(matched))
+			tokens = append(tokens, expressionToken{operatorToken, matched, 0, start})
+		}
+	}
+
+	return append(tokens, expressionToken{endToken, "end of expression", 0, len(runes)}), nil
+}
+
+// An expressionNode is a parsed part of an expression, either a constant or a closure
+type expressionNode struct {
+	constant bool
+	value    complex128
+	eval     expression
+}
+
+func constantNode(value complex128) expressionNode {
+	return expressionNode{constant: true, value: value}
+}
+
+func (n expressionNode) compile() expression {
+	if n.constant {
+		value := n.value
+		return func(env *expressionEnv) complex128 { return value }
+	}
+	return n.eval
+}
+
+// unary applies f to a node, folding constants
+func unary(n expressionNode, f func(complex128) complex128) expressionNode {
+	if n.constant {
+		return constantNode(f(n.value))
+	}
+	eval := n.eval
+	return expressionNode{eval: func(env *expressionEnv) complex128 { return f(eval(env)) }}
+}
+
+// binary applies the operator op to two nodes, folding constants
+func binary(op string, a expressionNode, b expressionNode) expressionNode {
+	f := binaryOperators[op]
+	if a.constant && b.constant {
+		return constantNode(f(a.value, b.value))
+	}
+
+	// The common arithmetic gets closures of its own, to save a call per operation
+	x, y := a.compile(), b.compile()
+	switch op {
+	case "+":
+		return expressionNode{eval: func(env *expressionEnv) complex128 { return x(env) + y(env) }}
+	case "-":
+		return expressionNode{eval: func(env *expressionEnv) complex128 { return x(env) - y(env) }}
+	case "*":
+		return expressionNode{eval: func(env *expressionEnv) complex128 { return x(env) * y(env) }}
+	case "/":
+		return expressionNode{eval: func(env *expressionEnv) complex128 { return x(env) / y(env) }}
+	}
+	return expressionNode{eval: func(env *expressionEnv) complex128 { return f(x(env), y(env)) }}
+}
+
+// power raises a node to a power, using repeated multiplication for whole constant exponents
+func power(a expressionNode, b expressionNode) expressionNode {
+	if b.constant && imag(b.value) == 0 && real(b.value) == math.Trunc(real(b.value)) && math.Abs(real(b.value)) <= 64 {
+		n := int(real(b.value))
+		return unary(a, func(z complex128) complex128 { return powInt(z, n) })
+	}
+	return binary("^", a, b)
+}
+
+func truth(b bool) complex128 {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+var binaryOperators = map[string]func(complex128, complex128) complex128{
+	"+":  func(a complex128, b complex128) complex128 { return a + b },
+	"-":  func(a complex128, b complex128) complex128 { return a - b },
+	"*":  func(a complex128, b complex128) complex128 { return a * b },
+	"/":  func(a complex128, b complex128) complex128 { return a / b },
+	"^":  cmplx.Pow,
+	"<":  func(a complex128, b complex128) complex128 { return truth(real(a) < real(b)) },
+	"<=": func(a complex128, b complex128) complex128 { return truth(real(a) <= real(b)) },
+	">":  func(a complex128, b complex128) complex128 { return truth(real(a) > real(b)) },
+	">=": func(a complex128, b complex128) complex128 { return truth(real(a) >= real(b)) },
+	"==": func(a complex128, b complex128) complex128 { return truth(a == b) },
+	"!=": func(a complex128, b complex128) complex128 { return truth(a != b) },
+	"&&": func(a complex128, b complex128) complex128 { return truth(a != 0 && b != 0) },
+	"||": func(a complex128, b complex128) complex128 { return truth(a != 0 || b != 0) },
+}
+
+func isExpressionVariable(name string) bool {
+	return name == "z" || name == "c" || name == "pixel"
+}
+
+func isIdentifier(name string) bool {
+	for i, r := range name {
+		if !(unicode.IsLetter(r) || r == '_' || (i > 0 && unicode.IsDigit(r))) {
+			return false
+		}
+	}
+	return name != ""
+}
+
+type expressionParser struct {
+	source string
+	tokens []expressionToken
+	next   int
+	params map[string]complex128
+}
+
+func (p *expressionParser) peek() expressionToken {
+	return p.tokens[p.next]
+}
+
+func (p *expressionParser) take() expressionToken {
+	t := p.tokens[p.next]
+	if t.kind != endToken {
+		p.next++
+	}
+	return t
+}
+
+// accept takes the next token if it is one of the operators given
+func (p *expressionParser) accept(ops ...string) (string, bool) {
+	t := p.peek()
+	if t.kind == operatorToken {
+		for _, op := range ops {
+			if t.text == op {
+				p.next++
+				return op, true
+			}
+		}
+	}
+	return "", false
+}
+
+func (p *expressionParser) errorAt(t expressionToken, format string, args ...interface{}) error {
+	return &expressionError{p.source, t.pos, fmt.Sprintf(format, args...)}
+}
+
+// binaryLevel parses operands separated by any of ops, all of the same precedence and left associative
+func (p *expressionParser) binaryLevel(operand func() (expressionNode, error), ops ...string) (expressionNode, error) {
+	n, err := operand()
+	if err != nil {
+		return n, err
+	}
+
+	for {
+		op, ok := p.accept(ops...)
+		if !ok {
+			return n, nil
+		}
+
+		right, err := operand()
+		if err != nil {
+			return n, err
+		}
+		n = binary(op, n, right)
+	}
+}
+
+func (p *expressionParser) parseOr() (expressionNode, error) {
+	return p.binaryLevel(p.parseAnd, "||")
+}
+
+func (p *expressionParser) parseAnd() (expressionNode, error) {
+	return p.binaryLevel(p.parseComparison, "&&")
+}
+
+func (p *expressionParser) parseComparison() (expressionNode, error) {
+	return p.binaryLevel(p.parseSum, "<=", ">=", "==", "!=", "<", ">")
+}
+
+func (p *expressionParser) parseSum() (expressionNode, error) {
+	return p.binaryLevel(p.parseProduct, "+", "-")
+}
+
+func (p *expressionParser) parseProduct() (expressionNode, error) {
+	n, err := p.parseUnary()
+	if err != nil {
+		return n, err
+	}
+
+	for {
+		var op, ok = p.accept("*", "/")
+		if !ok {
+			// Implicit multiplication, as in 2z or (z+1)(z+c)
+			t := p.peek()
+			if t.kind == endToken || (t.kind == operatorToken && t.text != "(") {
+				return n, nil
+			}
+			op = "*"
+		}
+
+		right, err := p.parseUnary()
+		if err != nil {
+			return n, err
+		}
+		n = binary(op, n, right)
+	}
+}
+
+func (p *expressionParser) parseUnary() (expressionNode, error) {
+	if op, ok := p.accept("-", "+"); ok {
+		n, err := p.parseUnary()
+		if err != nil || op == "+" {
+			return n, err
+		}
+		return unary(n, func(z complex128) complex128 { return -z }), nil
+	}
+	return p.parsePower()
+}
+
+// parsePower parses a ^ b, which binds tighter than a leading minus and associates to the right
+func (p *expressionParser) parsePower() (expressionNode, error) {
+	n, err := p.parsePrimary()
+	if err != nil {
+		return n, err
+	}
+
+	if _, ok := p.accept("^"); ok {
+		exponent, err := p.parseUnary()
+		if err != nil {
+			return n, err
+		}
+		return power(n, exponent), nil
+	}
+	return n, nil
+}
+
+func (p *expressionParser) parsePrimary() (expressionNode, error) {
+	t := p.take()
+
+	switch t.kind {
+	case numberToken:
+		return constantNode(complex(t.value, 0)), nil
+	case identifierToken:
+		if f, ok := expressionFunctions[t.text]; ok {
+			return p.parseCall(t, f)
+		}
+		if value, ok := p.params[t.text]; ok {
+			return constantNode(value), nil
+		}
+		if value, ok := expressionConstants[t.text]; ok {
+			return constantNode(value), nil
+		}
+		switch t.text {
+		case "z":
+			return expressionNode{eval: func(env *expressionEnv) complex128 { return env.z }}, nil
+		case "c":
+			return expressionNode{eval: func(env *expressionEnv) complex128 { return env.c }}, nil
+		case "pixel":
+			return expressionNode{eval: func(env *expressionEnv) complex128 { return env.pixel }}, nil
+		}
+		return expressionNode{}, p.errorAt(t, "unknown name %q", t.text)
+	case operatorToken:
+		if t.text == "(" {
+			n, err := p.parseOr()
+			if err != nil {
+				return n, err
+			}
+			if _, ok := p.accept(")"); !ok {
+				return n, p.errorAt(p.peek(), "expected ) to close the ( at position %d", t.pos+1)
+			}
+			return n, nil
+		}
+	}
+
+	return expressionNode{}, p.errorAt(t, "expected a number, name or (, got %q", t.text)
+}
+
+func (p *expressionParser) parseCall(name expressionToken, f func(complex128) complex128) (expressionNode, error) {
+	if _, ok := p.accept("("); !ok {
+		return expressionNode{}, p.errorAt(p.peek(), "expected ( after %s", name.text)
+	}
+
+	argument, err := p.parseOr()
+	if err != nil {
+		return argument, err
+	}
+
+	if _, ok := p.accept(","); ok {
+		return argument, p.errorAt(p.tokens[p.next-1], "%s takes one argument", name.text)
+	}
+	if _, ok := p.accept(")"); !ok {
+		return argument, p.errorAt(p.peek(), "expected ) to close the call to %s", name.text)
+	}
+
+	return unary(argument, f), nil
+}
@@ -0,0 +1,153 @@
+package main
+
+import (
+	"math/cmplx"
+	"strings"
+	"testing"
+)
+
+func TestExpressionsEvaluate(t *testing.T) {
+	env := &expressionEnv{z: complex(1, 2), c: complex(-0.5, 0.25), pixel: complex(3, 0)}
+	params := map[string]complex128{"k": 2}
+
+	cases := map[string]complex128{
+		"z^2 + c":         env.z*env.z + env.c,
+		"-z^2":            -(env.z * env.z),
+		"2z + 3(z+1)":     2*env.z + 3*(env.z+1),
+		"(z+1)(z+c)(z+i)": (env.z + 1) * (env.z + env.c) * (env.z + 1i),
+		"k*pixel - 1/2":   2*env.pixel - 0.5,
+		"z^-1":            1 / env.z,
+		"2^3^2":           512,
+		"sin(z) + abs(c)": cmplx.Sin(env.z) + complex(cmplx.Abs(env.c), 0),
+		"re(z) > im(z)":   0,
+		"abs(z) < 3 && 1": 1,
+		"1.5e1 + 2e":      15 + 2*2.718281828459045,
+	}
+
+	for source, want := range cases {
+		e, err := compileExpression(source, params)
+		if err != nil {
+			t.Errorf("%s didn't compile: %s", source, err)
+			continue
+		}
+		if got := e(env); cmplx.Abs(got-want) > 1e-12 {
+			t.Errorf("%s evaluated to %v, want %v.", source, got, want)
+		}
+	}
+}
+
+func TestExpressionErrorsGivePositions(t *testing.T) {
+	cases := map[string]string{
+		"z^2 + q":      "unknown name \"q\" at position 7",
+		"z^2 + (c":     "expected ) to close the ( at position 7",
+		"z^2 $ c":      "unexpected character '$' at position 5",
+		"sin(z, c)":    "sin takes one argument at position 6",
+		"z^2 + ":       "expected a number, name or (, got \"end of expression\" at position 7",
+		"z^2 + c)":     "unexpected \")\" at position 8",
+		"1.2.3 + z":    "invalid number \"1.2.3\" at position 1",
+		"cos z":        "expected ( after cos at position 5",
+		"z + * c":      "expected a number, name or (, got \"*\" at position 5",
+		"abs(z) >= 4)": "unexpected \")\" at position 12",
+	}
+
+	for source, want := range cases {
+		_, err := compileExpression(source, nil)
+		if err == nil {
+			t.Errorf("%s compiled, want an error.", source)
+			continue
+		}
+		if !strings.HasPrefix(err.Error(), want) {
+			t.Errorf("%s gave %q, want it to start %q.", source, err.Error(), want)
+		}
+	}
+}
+
+func TestParseParamsFoldsConstants(t *testing.T) {
+	params, err := parseParams("k=0.5, w=1+2i, v=k*pi")
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	if params["k"] != 0.5 || params["w"] != complex(1, 2) || cmplx.Abs(params["v"]-complex(0.5*3.141592653589793, 0)) > 1e-12 {
+		t.Errorf("Params were %v.", params)
+	}
+
+	for _, str := range []string{"k", "z=1", "sin=2", "k=z"} {
+		if _, err := parseParams(str); err == nil {
+			t.Errorf("Params %q parsed, want an error.", str)
+		}
+	}
+}
+
+func TestFormulaExpressionMatchesZ1ZcZi(t *testing.T) {
+	var c config
+	c.maxIterations = 100
+	c.expression = "(z+1)(z+c)(z+i)"
+	c.start = "0"
+	c.escapeCondition = "abs(z) >= 4"
+
+	m := newExpression()
+	f, err := m.formula(c)
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	for _, p := range [][2]float64{{-0.5, 0.2}, {0.3, -0.7}, {-1.2, 1.0}, {0.0, 0.0}} {
+		escaped, iterations, _, _ := f.mandelbrotForm().calculate(p[0], p[1], c)
+		wantEscaped, wantIterations, _, _ := z1ZcZiOrbit(0, 0, p[0], p[1], 16.0, c)
+		if escaped != wantEscaped || iterations != wantIterations {
+			t.Errorf("At %v the formula gave %t after %d, z1zczi %t after %d.", p, escaped, iterations, wantEscaped, wantIterations)
+		}
+	}
+}
+
+func TestFormulaIsOnlySmoothedWithoutAnEscapeCondition(t *testing.T) {
+	var c config
+	c.expression = "z - (z^3 - 1) / (3z^2) + c"
+	c.start = "1"
+	c.bailout = 2
+
+	m := newExpression()
+	for _, condition := range []string{"", "abs(z^3 - 1) < 0.001"} {
+		c.escapeCondition = condition
+		f, err := m.formula(c)
+		if err != nil {
+			t.Fatalf("Unexpected error: %s", err)
+		}
+		if settles := f.mandelbrotForm().settles; settles != (condition != "") {
+			t.Errorf("With escape condition %q the formula settles was %t.", condition, settles)
+		}
+	}
+}
+
+func TestFormulaSlicesTakePixelToBeC(t *testing.T) {
+	var c config
+	c.maxIterations = 100
+	c.bailout = 2
+	c.expression = "z^2 + pixel"
+	c.start = "0"
+
+	m := newExpression()
+	mandelbrot, err := m.formula(c)
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	// The Mandelbrot slice, asked for in the Julia form
+	c.julia = true
+	c.slice = "0,0,0,0;1,0,0,0;0,1,0,0"
+	f, err := m.formula(c)
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	s, _ := parseSlice(c.slice)
+	sliced := f.sliceForm(c, &m.Plane, s)
+
+	for _, p := range [][2]float64{{-0.5, 0.1}, {0.3, 0.6}, {1.0, 1.0}, {-1.9, 0.0}} {
+		escaped, iterations, _, _ := sliced.calculate(p[0], p[1], c)
+		wantEscaped, wantIterations, _, _ := mandelbrot.mandelbrotForm().calculate(p[0], p[1], c)
+		if escaped != wantEscaped || iterations != wantIterations {
+			t.Errorf("At %v the slice gave %t after %d, the Mandelbrot form %t after %d.", p, escaped, iterations, wantEscaped, wantIterations)
+		}
+	}
+}
@@ -0,0 +1,116 @@
+package main
+
+import (
+	"fmt"
+	"math"
+)
+
+// An orbitCalculator iterates a formula from the starting z with the constant c, until the orbit
+// passes bailout, the square of the escape radius, or runs out of iterations.
+type orbitCalculator func(zReal float64, zImag float64, cReal float64, cImag float64, bailout float64, config config) (escaped bool, iterations int, finalReal float64, finalImaginary float64)
+
+// A formula is an escape time fractal with two forms. In its Mandelbrot form c is the point on
+// the plane and z starts from a fixed point. In its Julia form c is fixed by -cr and -ci and
+// the point on the plane is the starting z.
+type formula struct {
+	name            string                                              // Prefix of generated file names
+	juliaName       string                                              // Prefix of generated file names in the Julia form. Defaults to name + "julia_"
+	orbit           orbitCalculator                                     // The formula itself
+	start           func(real float64, imag float64) (float64, float64) // Starting z of the Mandelbrot form for a given c
+	bailout         float64                                             // Squared escape radius of the Mandelbrot form
+	juliaBailout    func(cReal float64, cImag float64) float64          // Squared escape radius of the Julia set of c
+	degree          float64                                             // Degree of the formula near infinity, or 0 to estimate it
+	juliaPlane      Plane                                               // Default plane of the Julia form
+	escape          escapeCalculator                                    // Optional faster calculator for the Mandelbrot form
+	derivative      derivativeCalculator                                // Optional, for analytic lighting normals of the Mandelbrot form
+	juliaDerivative derivativeCalculator                                // Optional, for analytic lighting normals of the Julia form
+	settles         bool                                                // Orbits settle rather than pass an escape radius, so escape counts aren't smoothed
+}
+
+// processFormula draws a formula, or prints the coordinates of a pixel, in the form the config asks for
+func (p *Plane) processFormula(c config, f formula) {
+	var plane = *p
+	if c.julia {
+		plane = f.juliaPlane
+		c.bailout = f.juliaBailout(c.constR, c.constI)
+	}
+
+	if c.midX == -99.0 {
+		c.midX = (plane.rMax + plane.rMin) / 2.0
+	}
+
+	if c.midY == -99.0 {
+		c.midY = (plane.iMax + plane.iMin) / 2.0
+	}
+
+	if c.mode == imageMode {
+		if c.slice != "" {
+			s, err := parseSlice(c.slice)
+			if err != nil {
+				fmt.Println(err)
+				return
+			}
+			plane.render(c, f.sliceForm(c, &plane, s))
+		} else if c.julia {
+			plane.render(c, f.juliaForm(c))
+		} else {
+			plane.render(c, f.mandelbrotForm())
+		}
+	} else if c.mode == coordinatesMode {
+		var r, i = plane.calculateCoordinatesAtPoint(c)
+		fmt.Printf("%18.17e, %18.17e\n", r, i)
+	}
+}
+
+func (f formula) mandelbrotForm() escapeTimeFractal {
+	var calculate = f.escape
+	if calculate == nil {
+		calculate = func(real float64, imag float64, config config) (bool, int, float64, float64) {
+			zr, zi := f.start(real, imag)
+			return f.orbit(zr, zi, real, imag, f.bailout, config)
+		}
+	}
+
+	return escapeTimeFractal{name: f.name, calculate: calculate, derivative: f.derivative, smoothing: f.smoothing(f.bailout), settles: f.settles}
+}
+
+// juliaForm expects the config's bailout to have been set from juliaBailout
+func (f formula) juliaForm(c config) escapeTimeFractal {
+	var calculate escapeCalculator = func(real float64, imag float64, config config) (bool, int, float64, float64) {
+		return f.orbit(real, imag, config.constR, config.constI, config.bailout, config)
+	}
+
+	var name = f.juliaName
+	if name == "" {
+		name = f.name + "julia_"
+	}
+
+	return escapeTimeFractal{name: name, calculate: calculate, derivative: f.juliaDerivative, smoothing: f.smoothing(c.bailout), settles: f.settles}
+}
+
+func (f formula) smoothing(bailout float64) smoothing {
+	if f.degree <= 1 {
+		return smoothing{}
+	}
+	return smoothing{f.degree, math.Sqrt(bailout)}
+}
+
+// startAtZero starts orbits at 0, the critical point of most polynomial formulas
+func startAtZero(real float64, imag float64) (float64, float64) {
+	return 0.0, 0.0
+}
+
+// startAtC starts orbits at c, one step along from 0 for formulas of the form f(z) + c
+func startAtC(real float64, imag float64) (float64, float64) {
+	return real, imag
+}
+
+// polynomialJuliaBailout returns the squared escape radius for the Julia set of a formula
+// that grows like z**degree + c. Past max(2, 1 + |c|)**(1/(degree-1)) every orbit grows
+// without bound.
+func polynomialJuliaBailout(degree float64) func(cReal float64, cImag float64) float64 {
+	return func(cReal float64, cImag float64) float64 {
+		radius := math.Pow(max(2, 1+math.Hypot(cReal, cImag)), 1/(degree-1))
+		return radius * radius
+	}
+}
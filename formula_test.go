@@ -0,0 +1,40 @@
+package main
+
+import "testing"
+
+func TestJuliaFormFixesCAndStartsAtThePoint(t *testing.T) {
+	m := newMandelbrot()
+	var c config
+	c.maxIterations = 1000
+	c.bailout = 2.0
+	c.constR, c.constI = -1.0, 0.0
+
+	f := m.formula(c)
+	c.bailout = f.juliaBailout(c.constR, c.constI)
+	julia := f.juliaForm(c)
+
+	// z = 0 is on the period two cycle 0, -1 of z**2 - 1
+	if escaped, iterations, _, _ := julia.calculate(0.0, 0.0, c); escaped {
+		t.Errorf("Julia form escaped from 0 after %d iterations, want it bounded.", iterations)
+	}
+
+	if escaped, _, _, _ := julia.calculate(2.0, 0.0, c); !escaped {
+		t.Errorf("Julia form stayed bounded from 2, want it to escape.")
+	}
+
+	if julia.name != "julia_" {
+		t.Errorf("Julia form name was %q, want %q.", julia.name, "julia_")
+	}
+}
+
+func TestPolynomialJuliaBailoutGrowsWithC(t *testing.T) {
+	quadratic := polynomialJuliaBailout(2.0)
+
+	if got := quadratic(0.0, 0.0); got != 4.0 {
+		t.Errorf("Bailout for c = 0 was %f, want 4.", got)
+	}
+
+	if got := quadratic(3.0, 4.0); got != 36.0 {
+		t.Errorf("Bailout for |c| = 5 was %f, want 36.", got)
+	}
+}
@@ -0,0 +1,174 @@
+package main
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	_ "image/jpeg" // Register JPEG decoding for reference images
+	_ "image/png"  // Register PNG decoding for reference images
+	"math"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Ways of sampling a gradient from a reference image
+const (
+	lineSampling   = "line"   // Colours along a line across the image
+	kmeansSampling = "kmeans" // Dominant colours, ordered by luminance
+)
+
+const (
+	kmeansMaxSamples    = 20000
+	kmeansMaxIterations = 50
+)
+
+// extractGradient prints a gradient sampled from the reference image named in the config
+func extractGradient(c config) {
+	file, err := os.Open(c.source)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	defer file.Close()
+
+	img, _, err := image.Decode(file)
+	if err != nil {
+		fmt.Printf("%s: %v\n", c.source, err)
+		return
+	}
+
+	var stops []gradientStop
+	if c.sampling == lineSampling {
+		stops, err = sampleLine(img, c.sampleLine, c.stops)
+	} else {
+		stops, err = sampleDominantColours(img, c.stops)
+	}
+
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+
+	fmt.Println(formatGradient(stops))
+}
+
+// sampleLine takes count evenly spaced colours along a line given as "x0,y0,x1,y1",
+// in fractions of the image's width and height.
+func sampleLine(img image.Image, line string, count int) ([]gradientStop, error) {
+	fields := strings.Split(line, ",")
+	if len(fields) != 4 {
+		return nil, fmt.Errorf("sample line should be x0,y0,x1,y1, got %q", line)
+	}
+
+	var v [4]float64
+	for i, f := range fields {
+		var err error
+		if v[i], err = strconv.ParseFloat(strings.TrimSpace(f), 64); err != nil {
+			return nil, fmt.Errorf("sample line has an invalid value %q", f)
+		}
+	}
+
+	if count < 2 {
+		return nil, fmt.Errorf("a gradient needs at least 2 stops, got %d", count)
+	}
+
+	bounds := img.Bounds()
+	var stops = make([]gradientStop, count)
+	for i := range stops {
+		t := float64(i) / float64(count-1)
+		x := bounds.Min.X + int(math.Round(clamp(v[0]+(v[2]-v[0])*t, 0, 1)*float64(bounds.Dx()-1)))
+		y := bounds.Min.Y + int(math.Round(clamp(v[1]+(v[3]-v[1])*t, 0, 1)*float64(bounds.Dy()-1)))
+
+		colour := color.NRGBAModel.Convert(img.At(x, y)).(color.NRGBA)
+		colour.A = 255
+		stops[i] = gradientStop{t, colour}
+	}
+
+	return stops, nil
+}
+
+// sampleDominantColours clusters the image's colours with k-means and returns the cluster
+// centres as evenly spaced stops, darkest first. Clusters start at luminance quantiles so the
+// result is the same every time.
+func sampleDominantColours(img image.Image, count int) ([]gradientStop, error) {
+	if count < 2 {
+		return nil, fmt.Errorf("a gradient needs at least 2 stops, got %d", count)
+	}
+
+	bounds := img.Bounds()
+	stride := int(math.Max(1, math.Sqrt(float64(bounds.Dx()*bounds.Dy())/kmeansMaxSamples)))
+
+	var samples [][3]float64
+	for y := bounds.Min.Y; y < bounds.Max.Y; y += stride {
+		for x := bounds.Min.X; x < bounds.Max.X; x += stride {
+			c := color.NRGBAModel.Convert(img.At(x, y)).(color.NRGBA)
+			samples = append(samples, toColourSpace(oklabInterpolation, c))
+		}
+	}
+
+	// There can't be more clusters than samples, though tiny images still give two stops
+	if count > len(samples) && len(samples) >= 2 {
+		count = len(samples)
+	}
+
+	sort.Slice(samples, func(i, j int) bool { return samples[i][0] < samples[j][0] })
+
+	var centres = make([][3]float64, count)
+	for i := range centres {
+		centres[i] = samples[(2*i+1)*len(samples)/(2*count)]
+	}
+
+	var assignments = make([]int, len(samples))
+	for iteration := 0; iteration < kmeansMaxIterations; iteration++ {
+		var changed = false
+		for i, s := range samples {
+			nearest := 0
+			for j := range centres {
+				if colourDistance(s, centres[j]) < colourDistance(s, centres[nearest]) {
+					nearest = j
+				}
+			}
+			if nearest != assignments[i] || iteration == 0 {
+				changed = true
+			}
+			assignments[i] = nearest
+		}
+
+		if !changed {
+			break
+		}
+
+		var sums = make([][3]float64, count)
+		var sizes = make([]int, count)
+		for i, s := range samples {
+			for k := range s {
+				sums[assignments[i]][k] += s[k]
+			}
+			sizes[assignments[i]]++
+		}
+
+		for j := range centres {
+			if sizes[j] > 0 {
+				for k := range centres[j] {
+					centres[j][k] = sums[j][k] / float64(sizes[j])
+				}
+			}
+		}
+	}
+
+	sort.Slice(centres, func(i, j int) bool { return centres[i][0] < centres[j][0] })
+
+	var stops = make([]gradientStop, count)
+	for i, centre := range centres {
+		r, g, b := fromColourSpace(oklabInterpolation, centre)
+		stops[i] = gradientStop{float64(i) / float64(count-1), color.NRGBA{r, g, b, 255}}
+	}
+
+	return stops, nil
+}
+
+func colourDistance(a [3]float64, b [3]float64) float64 {
+	return (a[0]-b[0])*(a[0]-b[0]) + (a[1]-b[1])*(a[1]-b[1]) + (a[2]-b[2])*(a[2]-b[2])
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"image"
+	"image/color"
+	"testing"
+)
+
+func TestDominantColoursOfTwoSolidColours(t *testing.T) {
+	navy := color.NRGBA{10, 20, 120, 255}
+	orange := color.NRGBA{250, 160, 20, 255}
+
+	// A third navy and two thirds orange, so neither colour sits at the middle quantile
+	img := image.NewNRGBA(image.Rect(0, 0, 30, 20))
+	for y := 0; y < 20; y++ {
+		for x := 0; x < 30; x++ {
+			if x < 10 {
+				img.Set(x, y, navy)
+			} else {
+				img.Set(x, y, orange)
+			}
+		}
+	}
+
+	stops, err := sampleDominantColours(img, 2)
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if len(stops) != 2 {
+		t.Fatalf("Extracted %d stops, want 2.", len(stops))
+	}
+
+	// Darkest first, give or take rounding through OKLab
+	for i, want := range []color.NRGBA{navy, orange} {
+		got := stops[i].colour
+		if absDifference(got.R, want.R) > 1 || absDifference(got.G, want.G) > 1 || absDifference(got.B, want.B) > 1 {
+			t.Errorf("Stop %d was %v, want %v.", i, got, want)
+		}
+	}
+
+	if stops[0].position != 0 || stops[1].position != 1 {
+		t.Errorf("Stops were at %f and %f, want 0 and 1.", stops[0].position, stops[1].position)
+	}
+
+	// Clusters start from luminance quantiles rather than at random, so every run agrees
+	if again, _ := sampleDominantColours(img, 2); formatGradient(again) != formatGradient(stops) {
+		t.Errorf("A second extraction gave %s, want %s.", formatGradient(again), formatGradient(stops))
+	}
+}
+
+func TestExtractionNeedsTwoStops(t *testing.T) {
+	img := image.NewNRGBA(image.Rect(0, 0, 1, 1))
+	img.Set(0, 0, color.NRGBA{200, 40, 90, 255})
+
+	if _, err := sampleDominantColours(img, 1); err == nil {
+		t.Errorf("Extracted one dominant colour, want an error.")
+	}
+	if _, err := sampleLine(img, "0,0,1,1", 1); err == nil {
+		t.Errorf("Sampled one colour along a line, want an error.")
+	}
+
+	// A single pixel has one colour, which still makes a gradient from 0 to 1
+	for _, sample := range []func() ([]gradientStop, error){
+		func() ([]gradientStop, error) { return sampleDominantColours(img, 4) },
+		func() ([]gradientStop, error) { return sampleLine(img, "0,0,1,1", 4) },
+	} {
+		stops, err := sample()
+		if err != nil {
+			t.Fatalf("Unexpected error: %s", err)
+		}
+		if len(stops) < 2 || stops[0].position != 0 || stops[len(stops)-1].position != 1 {
+			t.Errorf("Stops of a single pixel were %+v, want them from 0 to 1.", stops)
+		}
+		if _, err := newGradient(formatGradient(stops), rgbInterpolation); err != nil {
+			t.Errorf("Stops of a single pixel didn't make a gradient: %s", err)
+		}
+	}
+}
+
+func absDifference(a uint8, b uint8) uint8 {
+	if a > b {
+		return a - b
+	}
+	return b - a
+}
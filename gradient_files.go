@@ -0,0 +1,292 @@
+package main
+
+import (
+	"bufio"
+	"encoding/hex"
+	"fmt"
+	"image/color"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// Gradient file formats supported
+const (
+	ggrExtension = ".ggr" // GIMP gradient
+	mapExtension = ".map" // Fractint / Ultra Fractal palette
+	cptExtension = ".cpt" // GMT colour palette table
+)
+
+// How many stops to sample from each segment of a GIMP gradient
+const ggrSamplesPerSegment = 8
+
+func loadGradientFile(path string) ([]gradientStop, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var stops []gradientStop
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ggrExtension:
+		stops, err = parseGGR(file)
+	case mapExtension:
+		stops, err = parseFractintMap(file)
+	case cptExtension:
+		stops, err = parseCPT(file)
+	default:
+		err = fmt.Errorf("unrecognised gradient file format %q", filepath.Ext(path))
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+
+	return normaliseStops(stops)
+}
+
+// parseGGR reads a GIMP gradient. Each segment is sampled at a handful of points using
+// its blending function, so curved and sinusoidal segments survive the conversion.
+// HSV segments are blended in RGB.
+func parseGGR(r io.Reader) ([]gradientStop, error) {
+	scanner := bufio.NewScanner(r)
+
+	if !scanner.Scan() || strings.TrimSpace(scanner.Text()) != "GIMP Gradient" {
+		return nil, fmt.Errorf("not a GIMP gradient")
+	}
+
+	var line string
+	for scanner.Scan() {
+		line = strings.TrimSpace(scanner.Text())
+		if !strings.HasPrefix(line, "Name:") {
+			break
+		}
+	}
+
+	count, err := strconv.Atoi(line)
+	if err != nil {
+		return nil, fmt.Errorf("invalid segment count %q", line)
+	}
+
+	var stops []gradientStop
+	for i := 0; i < count; i++ {
+		if !scanner.Scan() {
+			return nil, fmt.Errorf("expected %d segments, found %d", count, i)
+		}
+
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 11 {
+			return nil, fmt.Errorf("segment %d: expected at least 11 values, found %d", i+1, len(fields))
+		}
+
+		var v [11]float64
+		for j := range v {
+			if v[j], err = strconv.ParseFloat(fields[j], 64); err != nil {
+				return nil, fmt.Errorf("segment %d: invalid value %q", i+1, fields[j])
+			}
+		}
+
+		var blendType = 0
+		if len(fields) > 11 {
+			blendType, _ = strconv.Atoi(fields[11])
+		}
+
+		left, middle, right := v[0], v[1], v[2]
+		for k := 0; k < ggrSamplesPerSegment; k++ {
+			t := float64(k) / float64(ggrSamplesPerSegment)
+			f := ggrBlend(blendType, t, (middle-left)/(right-left))
+			stops = append(stops, gradientStop{left + t*(right-left), ggrColour(v[3:7], v[7:11], f)})
+		}
+
+		if i == count-1 {
+			stops = append(stops, gradientStop{right, ggrColour(v[3:7], v[7:11], 1.0)})
+		}
+	}
+
+	return stops, scanner.Err()
+}
+
+// ggrBlend returns how far between a segment's left and right colours a position t
+// within the segment lies, given the segment's relative midpoint.
+func ggrBlend(blendType int, t float64, middle float64) float64 {
+	var linear float64
+	if middle <= 0 || math.IsNaN(middle) {
+		linear = 0.5 + 0.5*t
+	} else if t <= middle {
+		linear = 0.5 * t / middle
+	} else if middle >= 1 {
+		linear = 1.0
+	} else {
+		linear = 0.5 + 0.5*(t-middle)/(1-middle)
+	}
+
+	switch blendType {
+	case 1: // Curved
+		if middle <= 0 || middle >= 1 {
+			return linear
+		}
+		return math.Pow(t, math.Log(0.5)/math.Log(middle))
+	case 2: // Sinusoidal
+		return (math.Sin(-math.Pi/2+math.Pi*linear) + 1) / 2
+	case 3: // Spherical, increasing
+		return math.Sqrt(1 - (linear-1)*(linear-1))
+	case 4: // Spherical, decreasing
+		return 1 - math.Sqrt(1-linear*linear)
+	case 5: // Step
+		if t >= middle {
+			return 1
+		}
+		return 0
+	}
+
+	return linear
+}
+
+func ggrColour(from []float64, to []float64, f float64) color.NRGBA {
+	var channel = func(i int) uint8 {
+		return uint8(math.Round(255 * clamp(from[i]+(to[i]-from[i])*f, 0, 1)))
+	}
+	return color.NRGBA{channel(0), channel(1), channel(2), channel(3)}
+}
+
+// parseFractintMap reads a Fractint palette, one "r g b" line per entry. Anything after
+// the third value on a line is a comment.
+func parseFractintMap(r io.Reader) ([]gradientStop, error) {
+	scanner := bufio.NewScanner(r)
+
+	var colours []color.NRGBA
+	for lineNumber := 1; scanner.Scan(); lineNumber++ {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 {
+			continue
+		}
+		if len(fields) < 3 {
+			return nil, fmt.Errorf("line %d: expected r g b values", lineNumber)
+		}
+
+		var rgb [3]uint8
+		for j := range rgb {
+			n, err := strconv.ParseUint(fields[j], 10, 8)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: invalid colour value %q", lineNumber, fields[j])
+			}
+			rgb[j] = uint8(n)
+		}
+		colours = append(colours, color.NRGBA{rgb[0], rgb[1], rgb[2], 255})
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	var stops = make([]gradientStop, len(colours))
+	for i, c := range colours {
+		stops[i] = gradientStop{float64(i) / math.Max(1, float64(len(colours)-1)), c}
+	}
+
+	return stops, nil
+}
+
+// parseCPT reads a GMT colour palette table in the RGB colour model. Slices may be written
+// as "z0 r g b z1 r g b", "z0 r/g/b z1 r/g/b", "z0 #rrggbb z1 #rrggbb" or "z0 grey z1 grey",
+// optionally followed by an L, U or B annotation flag. The z range is rescaled to [0, 1].
+func parseCPT(r io.Reader) ([]gradientStop, error) {
+	scanner := bufio.NewScanner(r)
+
+	var positions []float64
+	var colours []color.NRGBA
+	for lineNumber := 1; scanner.Scan(); lineNumber++ {
+		line := strings.TrimSpace(scanner.Text())
+
+		if strings.HasPrefix(line, "#") {
+			if strings.Contains(strings.ToUpper(line), "COLOR_MODEL") && !strings.Contains(strings.ToUpper(line), "RGB") {
+				return nil, fmt.Errorf("line %d: only the RGB colour model is supported", lineNumber)
+			}
+			continue
+		}
+
+		if i := strings.Index(line, ";"); i >= 0 {
+			line = line[:i]
+		}
+
+		fields := strings.Fields(strings.ReplaceAll(line, "/", " "))
+		if len(fields) == 0 || fields[0] == "B" || fields[0] == "F" || fields[0] == "N" {
+			continue
+		}
+
+		// Annotation flags only matter to GMT's colour bars
+		if last := fields[len(fields)-1]; len(fields)%2 == 1 && (last == "L" || last == "U" || last == "B") {
+			fields = fields[:len(fields)-1]
+		}
+
+		var colourFields int
+		switch len(fields) {
+		case 4:
+			colourFields = 1
+		case 8:
+			colourFields = 3
+		default:
+			return nil, fmt.Errorf("line %d: expected \"z0 colour z1 colour\"", lineNumber)
+		}
+
+		for _, slice := range [][]string{fields[:1+colourFields], fields[1+colourFields:]} {
+			z, err := strconv.ParseFloat(slice[0], 64)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: invalid z value %q", lineNumber, slice[0])
+			}
+
+			c, err := parseCPTColour(slice[1:])
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %v", lineNumber, err)
+			}
+
+			positions = append(positions, z)
+			colours = append(colours, c)
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	if len(positions) == 0 {
+		return nil, fmt.Errorf("no colour slices found")
+	}
+
+	zMin, zMax := positions[0], positions[len(positions)-1]
+	var stops = make([]gradientStop, len(positions))
+	for i := range positions {
+		var position = 0.0
+		if zMax != zMin {
+			position = (positions[i] - zMin) / (zMax - zMin)
+		}
+		stops[i] = gradientStop{position, colours[i]}
+	}
+
+	return stops, nil
+}
+
+func parseCPTColour(fields []string) (color.NRGBA, error) {
+	if len(fields) == 1 && strings.HasPrefix(fields[0], "#") {
+		b, err := hex.DecodeString(fields[0][1:])
+		if err != nil || len(b) != 3 {
+			return color.NRGBA{}, fmt.Errorf("invalid colour %q", fields[0])
+		}
+		return color.NRGBA{b[0], b[1], b[2], 255}, nil
+	}
+
+	var rgb [3]uint8
+	for j := range rgb {
+		n, err := strconv.ParseFloat(fields[j%len(fields)], 64)
+		if err != nil || n < 0 || n > 255 {
+			return color.NRGBA{}, fmt.Errorf("invalid colour value %q", fields[j%len(fields)])
+		}
+		rgb[j] = uint8(math.Round(n))
+	}
+
+	return color.NRGBA{rgb[0], rgb[1], rgb[2], 255}, nil
+}
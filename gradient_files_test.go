@@ -0,0 +1,95 @@
+package main
+
+import (
+	"image/color"
+	"strings"
+	"testing"
+)
+
+func TestParseGGRLinearSegment(t *testing.T) {
+	ggr := "GIMP Gradient\nName: Test\n1\n0.0 0.5 1.0 0 0 0 1 1 1 1 1 0 0\n"
+
+	stops, err := parseGGR(strings.NewReader(ggr))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if len(stops) != ggrSamplesPerSegment+1 {
+		t.Fatalf("Stop count was incorrect, got: %d, want: %d.", len(stops), ggrSamplesPerSegment+1)
+	}
+
+	middle := stops[ggrSamplesPerSegment/2]
+	if middle.position != 0.5 || middle.colour != (color.NRGBA{128, 128, 128, 255}) {
+		t.Errorf("Middle stop was incorrect, got: %v", middle)
+	}
+
+	last := stops[len(stops)-1]
+	if last.position != 1.0 || last.colour != (color.NRGBA{255, 255, 255, 255}) {
+		t.Errorf("Last stop was incorrect, got: %v", last)
+	}
+}
+
+func TestParseFractintMap(t *testing.T) {
+	stops, err := parseFractintMap(strings.NewReader("0 0 0 black\n\n255 0 0\n0 0 255 blue\n"))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	expected := []gradientStop{
+		{0.0, color.NRGBA{0, 0, 0, 255}},
+		{0.5, color.NRGBA{255, 0, 0, 255}},
+		{1.0, color.NRGBA{0, 0, 255, 255}},
+	}
+
+	if len(stops) != len(expected) {
+		t.Fatalf("Stop count was incorrect, got: %d, want: %d.", len(stops), len(expected))
+	}
+
+	for i := range expected {
+		if stops[i] != expected[i] {
+			t.Errorf("Stop %d was incorrect, got: %v, want: %v.", i, stops[i], expected[i])
+		}
+	}
+}
+
+func TestParseCPT(t *testing.T) {
+	cpt := "# COLOR_MODEL = RGB\n-10 0/0/0 0 255/0/0\n0 #ff0000 10 #0000ff ; label\n10 0 0 255 20 0 255 0 B\nB 0 0 0\nF 255 255 255\n"
+
+	stops, err := parseCPT(strings.NewReader(cpt))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	stops, _ = normaliseStops(stops)
+
+	if stops[0].position != 0.0 || stops[len(stops)-1].position != 1.0 {
+		t.Errorf("Positions were not rescaled, got: %f to %f.", stops[0].position, stops[len(stops)-1].position)
+	}
+
+	if stops[len(stops)-1].colour != (color.NRGBA{0, 255, 0, 255}) {
+		t.Errorf("Last colour was incorrect, got: %v", stops[len(stops)-1].colour)
+	}
+
+	for i := 1; i < len(stops); i++ {
+		if stops[i].position <= stops[i-1].position {
+			t.Errorf("Positions were not strictly increasing at stop %d.", i)
+		}
+	}
+}
+
+func TestParseCPTRejectsHSV(t *testing.T) {
+	if _, err := parseCPT(strings.NewReader("# COLOR_MODEL = HSV\n0 0-1-1 1 360-1-1\n")); err == nil {
+		t.Errorf("Expected an error for an HSV palette")
+	}
+}
+
+func TestParseGradientPreset(t *testing.T) {
+	stops, err := parseGradient("greyscale")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if len(stops) != 2 || stops[1].colour != (color.NRGBA{255, 255, 255, 255}) {
+		t.Errorf("Preset was incorrect, got: %v", stops)
+	}
+}
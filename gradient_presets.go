@@ -0,0 +1,32 @@
+package main
+
+import "sort"
+
+// Named gradients that can be passed to -g in place of a gradient string.
+// Each is expressed in the same [position, hex] form accepted on the command line.
+var gradientPresets = map[string]string{
+	"default":   defaultGradient,
+	"fire":      `[["0.0", "000000"],["0.25", "7f0000"],["0.5", "ff4500"],["0.75", "ffd700"],["1.0", "ffffff"]]`,
+	"ocean":     `[["0.0", "000814"],["0.3", "003566"],["0.6", "00a6fb"],["0.85", "caf0f8"],["1.0", "000814"]]`,
+	"sunset":    `[["0.0", "1a0533"],["0.3", "7b1e7a"],["0.55", "f2545b"],["0.8", "f9a03f"],["1.0", "fff1b5"]]`,
+	"forest":    `[["0.0", "081c15"],["0.35", "2d6a4f"],["0.65", "95d5b2"],["0.85", "d8f3dc"],["1.0", "081c15"]]`,
+	"electric":  `[["0.0", "000000"],["0.2", "3a0ca3"],["0.45", "4cc9f0"],["0.7", "ffffff"],["1.0", "000000"]]`,
+	"greyscale": `[["0.0", "000000"],["1.0", "ffffff"]]`,
+
+	// Perceptually uniform maps that keep their structure under colour vision deficiency,
+	// sampled from matplotlib's colormaps
+	"viridis": `[["0.0", "440154"],["0.125", "472c7a"],["0.25", "3b518b"],["0.375", "2c718e"],["0.5", "21908d"],["0.625", "27ad81"],["0.75", "5cc863"],["0.875", "aadc32"],["1.0", "fde725"]]`,
+	"cividis": `[["0.0", "00224e"],["0.111", "123570"],["0.222", "3b496c"],["0.333", "575d6d"],["0.444", "707173"],["0.556", "8a8678"],["0.667", "a59c74"],["0.778", "c3b369"],["0.889", "e1cc55"],["1.0", "fee838"]]`,
+	"magma":   `[["0.0", "000004"],["0.125", "1c1044"],["0.25", "4f127b"],["0.375", "812581"],["0.5", "b5367a"],["0.625", "e55064"],["0.75", "fb8761"],["0.875", "fec287"],["1.0", "fcfdbf"]]`,
+	"inferno": `[["0.0", "000004"],["0.125", "1f0c48"],["0.25", "550f6d"],["0.375", "88226a"],["0.5", "ba3655"],["0.625", "e35933"],["0.75", "f98e09"],["0.875", "f9cb35"],["1.0", "fcffa4"]]`,
+	"plasma":  `[["0.0", "0d0887"],["0.125", "4c02a1"],["0.25", "7e03a8"],["0.375", "a82296"],["0.5", "cb4679"],["0.625", "e56b5d"],["0.75", "f89441"],["0.875", "fdc328"],["1.0", "f0f921"]]`,
+}
+
+func presetNames() []string {
+	var names = make([]string, 0, len(gradientPresets))
+	for name := range gradientPresets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
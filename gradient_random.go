@@ -0,0 +1,86 @@
+package main
+
+import (
+	"fmt"
+	"image/color"
+	"math"
+	"math/rand"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const (
+	randomGradientPrefix = "random:"
+)
+
+// Hue offsets, in degrees, of the colour schemes random gradients are drawn from
+var hueSchemes = [][]float64{
+	{0, 30, -30},      // Analogous
+	{0, 180},          // Complementary
+	{0, 120, 240},     // Triadic
+	{0, 150, 210},     // Split complementary
+	{0, 90, 180, 270}, // Tetradic
+}
+
+// parseRandomGradient generates the gradient for a "random:<seed>" gradient string
+func parseRandomGradient(gradientStr string) ([]gradientStop, error) {
+	seed, err := strconv.ParseInt(strings.TrimPrefix(gradientStr, randomGradientPrefix), 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("gradient: random gradients need a whole number seed, got %q", gradientStr)
+	}
+	return normaliseStops(randomGradient(seed))
+}
+
+// randomGradient generates a gradient from a seed. Hues are taken from a colour scheme around
+// a random base hue, and stops alternate between dark and light so neighbouring stops always
+// contrast. The last stop repeats the first, so the gradient cycles cleanly in palette modes.
+// That takes an odd number of stops, or the stops either side of the repeat would both be dark
+// or both light.
+func randomGradient(seed int64) []gradientStop {
+	rng := rand.New(rand.NewSource(seed))
+
+	scheme := hueSchemes[rng.Intn(len(hueSchemes))]
+	baseHue := rng.Float64() * 360
+	count := 3 + 2*rng.Intn(3)
+	dark := rng.Intn(2) == 0
+
+	var positions = make([]float64, count)
+	for i := 1; i < count-1; i++ {
+		positions[i] = rng.Float64()
+	}
+	positions[count-1] = 1.0
+	sort.Float64s(positions)
+
+	var stops = make([]gradientStop, count)
+	for i := 0; i < count-1; i++ {
+		lightness := 0.75 + 0.2*rng.Float64()
+		if dark {
+			lightness = 0.1 + 0.25*rng.Float64()
+		}
+		dark = !dark
+
+		hue := baseHue + scheme[rng.Intn(len(scheme))] + 20*(rng.Float64()-0.5)
+		chroma := 0.05 + 0.15*rng.Float64()
+
+		stops[i] = gradientStop{positions[i], oklchColour(lightness, chroma, hue)}
+	}
+	stops[count-1] = gradientStop{1.0, stops[0].colour}
+
+	return stops
+}
+
+// oklchColour converts an OKLCh colour to sRGB, reducing chroma until it fits in the sRGB gamut
+func oklchColour(lightness float64, chroma float64, hue float64) color.NRGBA {
+	radians := hue * math.Pi / 180
+
+	for ; chroma > 0.001; chroma *= 0.9 {
+		r, g, b := oklabToLinear(lightness, chroma*math.Cos(radians), chroma*math.Sin(radians))
+		if r >= 0 && r <= 1 && g >= 0 && g <= 1 && b >= 0 && b <= 1 {
+			break
+		}
+	}
+
+	r, g, b := fromColourSpace(oklabInterpolation, [3]float64{lightness, chroma * math.Cos(radians), chroma * math.Sin(radians)})
+	return color.NRGBA{r, g, b, 255}
+}
@@ -3,110 +3,285 @@ package main
 import (
 	"encoding/hex"
 	"encoding/json"
+	"fmt"
 	"image/color"
 	"math"
+	"path/filepath"
+	"sort"
 	"strconv"
+	"strings"
 
 	"github.com/gilmae/interpolation"
 )
 
-const ( // Colour Modes
-	trueColouring   = "true"
-	smoothColouring = "smooth"
-	noColouring     = "none"
-	bandedColouring = "banded"
-)
-
 const (
-	paletteLength = 16
+	defaultPaletteLength   = 16
+	minimumSmoothingRadius = 1.1 // Estimated escape radii closer to 1 give no useful degree
 )
 
-var redInterpolant interpolation.MonotonicCubic
-var greenInterpolant interpolation.MonotonicCubic
-var blueInterpolant interpolation.MonotonicCubic
+// A Gradient maps positions from 0.0 to 1.0 onto colours, interpolating between stops
+type Gradient struct {
+	stops    []gradientStop                  // Stops the gradient was built from
+	space    string                          // Colour space the channels are interpolated in
+	channels [3]interpolation.MonotonicCubic // Colour channels, in the coordinates of space
+	alpha    interpolation.MonotonicCubic
+}
 
-func initialiseGradient(gradientStr string) {
-	var g [][]string
+// A gradientStop is a single colour fixed at a position along a gradient
+type gradientStop struct {
+	position float64     // Position along the gradient, from 0.0 to 1.0
+	colour   color.NRGBA // Colour at that position
+}
+
+// initialiseGradient builds the gradient described by gradientStr, falling back to the
+// default gradient if it can't be parsed. space is the colour space to interpolate in.
+func initialiseGradient(gradientStr string, space string) Gradient {
+	g, err := newGradient(gradientStr, space)
+	if err != nil {
+		fmt.Println(err)
+		g, _ = newGradient(defaultGradient, space)
+	}
+	return g
+}
 
-	byt := []byte(gradientStr)
-	_ = json.Unmarshal(byt, &g)
-	var size = len(g)
+func newGradient(gradientStr string, space string) (Gradient, error) {
+	stops, err := parseGradient(gradientStr)
+	if err != nil {
+		return Gradient{}, err
+	}
+	return newGradientFromStops(stops, space), nil
+}
+
+func newGradientFromStops(stops []gradientStop, space string) Gradient {
+	var size = len(stops)
 	var xSequence = make([]float64, size)
-	var redpoints = make([]float64, size)
-	var greenpoints = make([]float64, size)
-	var bluepoints = make([]float64, size)
+	var channelpoints [3][]float64
+	var alphapoints = make([]float64, size)
 
-	for i, v := range g {
-		xSequence[i], _ = strconv.ParseFloat(v[0], 64)
-		b, _ := hex.DecodeString(v[1])
-		redpoints[i] = float64(b[0])
-		greenpoints[i] = float64(b[1])
-		bluepoints[i] = float64(b[2])
+	for i := range channelpoints {
+		channelpoints[i] = make([]float64, size)
+	}
+
+	for i, s := range stops {
+		xSequence[i] = s.position
+		v := toColourSpace(space, s.colour)
+		for j := range channelpoints {
+			channelpoints[j][i] = v[j]
+		}
+		alphapoints[i] = float64(s.colour.A)
+	}
+
+	var g = Gradient{stops: stops, space: space}
+	for i := range channelpoints {
+		g.channels[i] = interpolation.CreateMonotonicCubic(xSequence, channelpoints[i])
 	}
+	g.alpha = interpolation.CreateMonotonicCubic(xSequence, alphapoints)
 
-	redInterpolant = interpolation.CreateMonotonicCubic(xSequence, redpoints)
-	greenInterpolant = interpolation.CreateMonotonicCubic(xSequence, greenpoints)
-	blueInterpolant = interpolation.CreateMonotonicCubic(xSequence, bluepoints)
+	return g
 }
 
-func getPixelColour(point PlottedPoint, maxIterations int, colourMode string) color.NRGBA {
-	if colourMode == trueColouring {
+// colourAt returns the colour at a position along the gradient. Positions outside
+// 0.0 to 1.0 are clamped to the ends.
+func (g Gradient) colourAt(position float64) color.NRGBA {
+	position = clamp(position, 0.0, 1.0)
 
-		var gradientPosition = float64(point.Iterations) / float64(maxIterations)
-		var redpoint = redInterpolant(gradientPosition)
-		var greenpoint = greenInterpolant(gradientPosition)
-		var bluepoint = blueInterpolant(gradientPosition)
+	var v [3]float64
+	for i, channel := range g.channels {
+		v[i] = channel(position)
+	}
+
+	r, gr, b := fromColourSpace(g.space, v)
+	return color.NRGBA{r, gr, b, uint8(clamp(g.alpha(position), 0, 255))}
+}
 
-		return color.NRGBA{uint8(redpoint), uint8(greenpoint), uint8(bluepoint), 255}
-	} else if colourMode == smoothColouring {
+// parseGradient resolves a gradient given as a preset name, "random:<seed>", a path to a
+// gradient file (.ggr, .map or .cpt) or an inline JSON list of [position, hex] pairs.
+func parseGradient(gradientStr string) ([]gradientStop, error) {
+	if preset, ok := gradientPresets[gradientStr]; ok {
+		gradientStr = preset
+	}
 
-		palette := fillPalette()
+	if strings.HasPrefix(gradientStr, randomGradientPrefix) {
+		return parseRandomGradient(gradientStr)
+	}
 
-		jitteredEscape := jitter(point)
-		index1 := int(math.Abs(jitteredEscape))
-		t2 := jitteredEscape - float64(index1)
-		t1 := 1 - t2
+	switch strings.ToLower(filepath.Ext(gradientStr)) {
+	case ggrExtension, mapExtension, cptExtension:
+		return loadGradientFile(gradientStr)
+	}
 
-		index1 = index1 % len(palette)
-		index2 := (index1 + 1) % len(palette)
+	var g [][]string
+	if err := json.Unmarshal([]byte(gradientStr), &g); err != nil {
+		return nil, fmt.Errorf("gradient: %v", err)
+	}
 
-		clr1 := palette[index1]
-		clr2 := palette[index2]
+	var stops = make([]gradientStop, len(g))
+	for i, v := range g {
+		if len(v) != 2 {
+			return nil, fmt.Errorf("gradient: stop %d should be a [position, hex] pair", i)
+		}
 
-		r := float64(clr1.R)*t1 + float64(clr2.R)*t2
-		g := float64(clr1.G)*t1 + float64(clr2.G)*t2
-		b := float64(clr1.B)*t1 + float64(clr2.B)*t2
+		position, err := strconv.ParseFloat(v[0], 64)
+		if err != nil {
+			return nil, fmt.Errorf("gradient: stop %d has an invalid position %q", i, v[0])
+		}
 
-		return color.NRGBA{uint8(r), uint8(g), uint8(b), 255}
+		b, err := hex.DecodeString(v[1])
+		if err != nil || (len(b) != 3 && len(b) != 4) {
+			return nil, fmt.Errorf("gradient: stop %d has an invalid colour %q", i, v[1])
+		}
 
-	} else if colourMode == bandedColouring {
+		var alpha uint8 = 255
+		if len(b) == 4 {
+			alpha = b[3]
+		}
 
-		palette := fillPalette()
-		return palette[point.Iterations%len(palette)]
+		stops[i] = gradientStop{position, color.NRGBA{b[0], b[1], b[2], alpha}}
+	}
 
-	} else { // i.e. noColouring
+	return normaliseStops(stops)
+}
 
-		return color.NRGBA{255, 255, 255, 255}
+// formatGradient writes stops in the JSON form parseGradient accepts, so they can be passed to -g.
+func formatGradient(stops []gradientStop) string {
+	var g = make([][]string, len(stops))
+	for i, s := range stops {
+		colour := fmt.Sprintf("%02x%02x%02x", s.colour.R, s.colour.G, s.colour.B)
+		if s.colour.A != 255 {
+			colour += fmt.Sprintf("%02x", s.colour.A)
+		}
+		g[i] = []string{strconv.FormatFloat(s.position, 'f', -1, 64), colour}
 	}
+
+	b, _ := json.Marshal(g)
+	return string(b)
 }
 
-func fillPalette() []color.NRGBA {
-	var palette = make([]color.NRGBA, paletteLength)
-	for i := 0; i < paletteLength; i++ {
-		var point = float64(i) / float64(paletteLength)
+// normaliseStops sorts stops by position and nudges apart any that share a position,
+// as the interpolants need strictly increasing positions.
+func normaliseStops(stops []gradientStop) ([]gradientStop, error) {
+	if len(stops) == 0 {
+		return nil, fmt.Errorf("gradient: no stops found")
+	}
 
-		var redpoint = redInterpolant(point)
-		var greenpoint = greenInterpolant(point)
-		var bluepoint = blueInterpolant(point)
+	sort.SliceStable(stops, func(i, j int) bool { return stops[i].position < stops[j].position })
 
-		palette[i] = color.NRGBA{uint8(redpoint), uint8(greenpoint), uint8(bluepoint), 255}
+	for i := 1; i < len(stops); i++ {
+		if stops[i].position <= stops[i-1].position {
+			stops[i].position = stops[i-1].position + 1e-9
+		}
+	}
+
+	return stops, nil
+}
+
+// fillPalette samples the gradient into the lookup table used by smooth and banded colouring.
+// The gradient is repeated paletteRepeat times across the paletteLength entries.
+func fillPalette(gradient Gradient, c config) []color.NRGBA {
+	var length = c.paletteLength
+	if length < 1 {
+		length = defaultPaletteLength
+	}
+
+	var repeat = c.paletteRepeat
+	if repeat < 1 {
+		repeat = 1
+	}
+
+	var palette = make([]color.NRGBA, length)
+	for i := 0; i < length; i++ {
+		var point = float64(i*repeat%length) / float64(length)
+		palette[i] = gradient.colourAt(point)
 	}
 
 	return palette
 
 }
 
-func jitter(p PlottedPoint) float64 {
+// blendPaletteEntries linearly blends the two palette entries either side of a fractional index.
+func blendPaletteEntries(palette []color.NRGBA, index float64) color.NRGBA {
+	index1 := math.Floor(index)
+	t2 := index - index1
+	t1 := 1 - t2
+
+	clr1 := paletteEntry(palette, int(index1))
+	clr2 := paletteEntry(palette, int(index1)+1)
+
+	r := float64(clr1.R)*t1 + float64(clr2.R)*t2
+	g := float64(clr1.G)*t1 + float64(clr2.G)*t2
+	b := float64(clr1.B)*t1 + float64(clr2.B)*t2
+	a := float64(clr1.A)*t1 + float64(clr2.A)*t2
+
+	return color.NRGBA{uint8(r), uint8(g), uint8(b), uint8(a)}
+}
+
+// paletteEntry looks up a palette index, wrapping around in both directions.
+func paletteEntry(palette []color.NRGBA, index int) color.NRGBA {
+	index = index % len(palette)
+	if index < 0 {
+		index += len(palette)
+	}
+	return palette[index]
+}
+
+// A smoothing describes how quickly orbits grow once they escape, which is what turns a
+// whole number of iterations into a continuous escape value.
+type smoothing struct {
+	degree float64 // Degree of the map near infinity, e.g. 2 for z**2 + c
+	radius float64 // Escape radius the orbit had to pass
+}
+
+// jitter returns the continuous escape value of a point,
+// n + 1 - log(log|z| / log R) / log d. Where that isn't defined it falls back to n.
+func jitter(p PlottedPoint, s smoothing) float64 {
+	var iterations = float64(p.Iterations)
+	if s.degree <= 1 || s.radius <= 1 {
+		return iterations
+	}
+
 	magnitude := math.Sqrt(p.real*p.real + p.imag*p.imag)
-	return float64(p.Iterations+1) - (math.Log(math.Log(magnitude)))/math.Log(2.0)
+	ratio := max(math.Log(magnitude)/math.Log(s.radius), 1.0)
+	value := iterations + 1 - math.Log(ratio)/math.Log(s.degree)
+
+	if math.IsNaN(value) || math.IsInf(value, 0) {
+		return iterations
+	}
+	return value
+}
+
+// estimateSmoothing fills in the degree or radius of a fractal that doesn't declare them
+// from the escaped orbits in the field. The smallest final |z| approximates the escape radius,
+// and the furthest any orbit overshot it approximates the degree. Radii too close to 1 give
+// no useful degree, so leave the render unsmoothed.
+func estimateSmoothing(s smoothing, field *escapeField) smoothing {
+	if s.degree > 0 && s.radius > 0 {
+		return s
+	}
+
+	var magnitudes []float64
+	for _, p := range field.points {
+		magnitude := math.Sqrt(p.real*p.real + p.imag*p.imag)
+		if p.Escaped && magnitude > 1 && !math.IsInf(magnitude, 0) && !math.IsNaN(magnitude) {
+			magnitudes = append(magnitudes, magnitude)
+		}
+	}
+
+	if len(magnitudes) == 0 {
+		return s
+	}
+	sort.Float64s(magnitudes)
+
+	if s.radius <= 0 {
+		s.radius = magnitudes[0]
+	}
+
+	if s.degree <= 0 && s.radius >= minimumSmoothingRadius {
+		// Take a high percentile rather than the maximum so a few wild orbits don't dominate
+		degree := math.Log(magnitudes[len(magnitudes)*99/100]) / math.Log(s.radius)
+		if degree > 1 && !math.IsInf(degree, 0) {
+			s.degree = degree
+		}
+	}
+
+	return s
 }
@@ -0,0 +1,192 @@
+package main
+
+import (
+	"flag"
+	"io/ioutil"
+	"math"
+	"testing"
+)
+
+func TestJitterIsContinuousAcrossBands(t *testing.T) {
+	s := smoothing{2.0, 2.0}
+
+	// An orbit that only just passes the radius after n iterations matches one that fell
+	// just short of it and passes the square of the radius after n + 1
+	before := jitter(PlottedPoint{Iterations: 5, real: 2.0}, s)
+	after := jitter(PlottedPoint{Iterations: 6, real: 4.0}, s)
+
+	if math.Abs(before-after) > 1e-9 {
+		t.Errorf("Smooth values were discontinuous, got: %f and %f.", before, after)
+	}
+}
+
+func TestJitterUsesDegree(t *testing.T) {
+	s := smoothing{3.0, 4.0}
+
+	value := jitter(PlottedPoint{Iterations: 10, real: 64.0}, s)
+	expected := 10.0
+
+	if math.Abs(value-expected) > 1e-9 {
+		t.Errorf("Smooth value was incorrect, got: %f, want: %f.", value, expected)
+	}
+}
+
+func TestJitterGuardsUndefinedValues(t *testing.T) {
+	points := []PlottedPoint{
+		{Iterations: 7, real: 0.5},
+		{Iterations: 7, real: math.Inf(1)},
+		{Iterations: 7, real: math.NaN()},
+	}
+
+	for _, p := range points {
+		value := jitter(p, smoothing{2.0, 2.0})
+		if math.IsNaN(value) || math.IsInf(value, 0) {
+			t.Errorf("Smooth value for %f was not guarded, got: %f.", p.real, value)
+		}
+	}
+
+	if value := jitter(PlottedPoint{Iterations: 7, real: 3.0}, smoothing{}); value != 7.0 {
+		t.Errorf("Smooth value without a degree was incorrect, got: %f, want: 7.", value)
+	}
+}
+
+func TestEstimateSmoothingNeedsARadiusClearOf1(t *testing.T) {
+	// Orbits that escaped past |z| = 2.5 and were squared once more
+	field := &escapeField{3, 1, []PlottedPoint{{X: 0, real: 2.5, Escaped: true}, {X: 1, real: 3.5, Escaped: true}, {X: 2, real: 6.25, Escaped: true}}}
+	if s := estimateSmoothing(smoothing{}, field); s.radius != 2.5 || math.Abs(s.degree-2) > 1e-9 {
+		t.Errorf("Estimated smoothing was %+v, want degree 2 and radius 2.5.", s)
+	}
+
+	// An orbit that only just passed 1 would put the degree in the thousands
+	field.points[0].real = 1.0001
+	if s := estimateSmoothing(smoothing{}, field); s.degree != 0 {
+		t.Errorf("Estimated smoothing was %+v, want no degree from a radius of 1.0001.", s)
+	}
+	if s := estimateSmoothing(smoothing{radius: 1.0001}, field); s.degree != 0 {
+		t.Errorf("Estimated smoothing was %+v, want no degree for a declared radius of 1.0001.", s)
+	}
+}
+
+func TestRandomGradientIsReproducible(t *testing.T) {
+	first, err := parseGradient("random:42")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	second, _ := parseGradient("random:42")
+	if formatGradient(first) != formatGradient(second) {
+		t.Errorf("Gradients from the same seed differed, got: %s and %s.", formatGradient(first), formatGradient(second))
+	}
+
+	if first[0].colour != first[len(first)-1].colour {
+		t.Errorf("Random gradient should end where it starts, got: %v and %v.", first[0].colour, first[len(first)-1].colour)
+	}
+
+	if _, err := parseGradient("random:forty-two"); err == nil {
+		t.Errorf("Expected an error for a seed that isn't a number")
+	}
+}
+
+func TestRandomGradientStopsContrastAllTheWayRound(t *testing.T) {
+	// A hundred seeds covers every stop count, including those that used to be even
+	for seed := int64(0); seed < 100; seed++ {
+		stops := randomGradient(seed)
+
+		// The last stop repeats the first, so the pairs include the one across the wrap
+		for i := 1; i < len(stops); i++ {
+			before := toColourSpace(oklabInterpolation, stops[i-1].colour)[0]
+			after := toColourSpace(oklabInterpolation, stops[i].colour)[0]
+			if math.Abs(before-after) < 0.3 {
+				t.Errorf("Seed %d has stops %d and %d of %d at lightness %.2f and %.2f, want them to contrast.", seed, i-1, i, len(stops), before, after)
+			}
+		}
+	}
+}
+
+// parsePaletteFlags reads palette options as they would be given on the command line
+func parsePaletteFlags(args ...string) (config, error) {
+	var c config
+	flags := flag.NewFlagSet("test", flag.ContinueOnError)
+	flags.SetOutput(ioutil.Discard)
+	defineFlags(flags, &c)
+	err := flags.Parse(args)
+	return c, err
+}
+
+func TestPaletteRepeatSamplesTheGradientAgain(t *testing.T) {
+	var c config
+	c.paletteLength, c.paletteRepeat = 8, 2
+
+	gradient := initialiseGradient("greyscale", rgbInterpolation)
+	palette := fillPalette(gradient, c)
+
+	// Twice across eight entries, so every fourth entry is back at the start of the gradient
+	for i, colour := range palette {
+		if want := gradient.colourAt(float64(i%4) / 4); colour != want {
+			t.Errorf("Palette entry %d was %v, want %v.", i, colour, want)
+		}
+	}
+}
+
+func TestPaletteOffsetAndDensityMoveEscapesAlongThePalette(t *testing.T) {
+	ctx := newTestColouringContext(bandedColouring, 0, 1, 2, 3, 4, 5, 6)
+	ctx.config.paletteOffset = 1
+	ctx.config.colourDensity = 0.5
+
+	// Half an entry per iteration, starting from the second entry
+	for _, point := range ctx.field.points {
+		if colour, want := getPixelColour(point, ctx), ctx.palette[(point.Iterations/2+1)%4]; colour != want {
+			t.Errorf("%d iterations were coloured %v, want %v.", point.Iterations, colour, want)
+		}
+	}
+
+	// Smooth colouring blends the entries either side of a fractional offset
+	ctx.config.colourMode = smoothColouring
+	ctx.config.paletteOffset = 1.5
+	ctx.config.colourDensity = 0
+	colour := getPixelColour(ctx.field.points[0], ctx)
+	if want := (int(ctx.palette[1].R) + int(ctx.palette[2].R)) / 2; colour.R < uint8(want) || colour.R > uint8(want+1) {
+		t.Errorf("An offset of 1.5 was coloured %v, want halfway between %v and %v.", colour, ctx.palette[1], ctx.palette[2])
+	}
+}
+
+func TestPaletteParametersOutOfRangeFallBack(t *testing.T) {
+	c, err := parsePaletteFlags("-pl", "0", "-pr", "-2")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	palette := fillPalette(initialiseGradient("greyscale", rgbInterpolation), c)
+	if len(palette) != defaultPaletteLength {
+		t.Errorf("Palette length was %d, want the default %d.", len(palette), defaultPaletteLength)
+	}
+
+	// Repeating once, the palette climbs the greyscale gradient from black
+	for i := 1; i < len(palette); i++ {
+		if palette[i].R < palette[i-1].R {
+			t.Fatalf("Palette entry %d is darker than the one before, want the gradient to repeat once.", i)
+		}
+	}
+}
+
+func TestGradientInterpolatesAlpha(t *testing.T) {
+	for _, space := range []string{rgbInterpolation, linearInterpolation, oklabInterpolation} {
+		g, err := newGradient(`[["0.0", "ff000000"],["1.0", "ff0000ff"]]`, space)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+
+		if start, end := g.colourAt(0).A, g.colourAt(1).A; start != 0 || end != 255 {
+			t.Errorf("In %s alpha ran from %d to %d, want 0 to 255.", space, start, end)
+		}
+
+		if mid := g.colourAt(0.5); mid.A < 127 || mid.A > 128 || mid.R != 255 {
+			t.Errorf("In %s the midpoint was %v, want half transparent red.", space, mid)
+		}
+	}
+
+	stops, _ := parseGradient(`[["0.0", "ff000000"],["1.0", "ff0000ff"]]`)
+	if formatted := formatGradient(stops); formatted != `[["0","ff000000"],["1","ff0000"]]` {
+		t.Errorf("Formatted gradient was %s, want transparency kept only where it is set.", formatted)
+	}
+}
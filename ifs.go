@@ -0,0 +1,344 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"image/color"
+	"io/ioutil"
+	"math"
+	"math/rand"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// Ways of colouring an IFS render
+const (
+	densityIFSColouring = "density" // By the log of how often the chaos game visits each pixel
+	mapIFSColouring     = "map"     // By the maps that last moved the points in each pixel, shaded by density
+)
+
+const (
+	ifsWarmup       = 20     // Steps of the chaos game before points are on the attractor
+	ifsBoundsPoints = 100000 // Points of the attractor taken to frame the render
+)
+
+// An affineMap moves (x, y) to (a*x + b*y + e, c*x + d*y + f). The chaos game picks maps in
+// proportion to their weights, which default to the area the map shrinks the plane to.
+// Maps are read from a JSON file given to -ifs, e.g.
+// [{"a": 0.5, "d": 0.5}, {"a": 0.5, "d": 0.5, "e": 0.5}, {"a": 0.5, "d": 0.5, "e": 0.25, "f": 0.5}]
+type affineMap struct {
+	A      float64  `json:"a"`
+	B      float64  `json:"b"`
+	C      float64  `json:"c"`
+	D      float64  `json:"d"`
+	E      float64  `json:"e"`
+	F      float64  `json:"f"`
+	Weight *float64 `json:"weight"`
+}
+
+// weighted returns the map with the given weight
+func weighted(m affineMap, weight float64) affineMap {
+	m.Weight = &weight
+	return m
+}
+
+// ifsPresets are the maps of well known attractors, by name
+var ifsPresets = map[string][]affineMap{
+	"fern": {
+		weighted(affineMap{D: 0.16}, 0.01),
+		weighted(affineMap{A: 0.85, B: 0.04, C: -0.04, D: 0.85, F: 1.6}, 0.85),
+		weighted(affineMap{A: 0.2, B: -0.26, C: 0.23, D: 0.22, F: 1.6}, 0.07),
+		weighted(affineMap{A: -0.15, B: 0.28, C: 0.26, D: 0.24, F: 0.44}, 0.07),
+	},
+	"sierpinski": {
+		{A: 0.5, D: 0.5},
+		{A: 0.5, D: 0.5, E: 0.5},
+		{A: 0.5, D: 0.5, E: 0.25, F: math.Sqrt(3) / 4},
+	},
+	"dragon": {
+		{A: 0.5, B: -0.5, C: 0.5, D: 0.5},
+		{A: -0.5, B: -0.5, C: 0.5, D: -0.5, E: 1},
+	},
+	"levy": {
+		{A: 0.5, B: -0.5, C: 0.5, D: 0.5},
+		{A: 0.5, B: 0.5, C: -0.5, D: 0.5, E: 0.5, F: 0.5},
+	},
+}
+
+// ifsPresetNames returns the names of the IFS presets, sorted
+func ifsPresetNames() []string {
+	var names []string
+	for name := range ifsPresets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// apply moves a point by the map
+func (m affineMap) apply(x float64, y float64) (float64, float64) {
+	return m.A*x + m.B*y + m.E, m.C*x + m.D*y + m.F
+}
+
+// weight returns the map's weight. Without one it is the absolute determinant, the factor the
+// map scales areas by, with a floor so maps that flatten the plane are still picked.
+func (m affineMap) weight() float64 {
+	if m.Weight != nil {
+		return *m.Weight
+	}
+	return math.Max(0.01, math.Abs(m.A*m.D-m.B*m.C))
+}
+
+// loadIFS returns the maps of a preset, or reads them from a JSON file
+func loadIFS(name string) ([]affineMap, error) {
+	if maps, ok := ifsPresets[name]; ok {
+		return maps, nil
+	}
+
+	b, err := ioutil.ReadFile(name)
+	if err != nil {
+		return nil, fmt.Errorf("ifs: %q is neither a preset (%s) nor a readable file: %v", name, strings.Join(ifsPresetNames(), ", "), err)
+	}
+
+	var maps []affineMap
+	if err = json.Unmarshal(b, &maps); err != nil {
+		return nil, fmt.Errorf("%s: %v", name, err)
+	}
+
+	if len(maps) == 0 {
+		return nil, fmt.Errorf("%s: an IFS needs at least one map", name)
+	}
+
+	var total float64
+	for i, m := range maps {
+		// A map that doesn't shrink areas can't be contractive, so the chaos game would wander off
+		if math.Abs(m.A*m.D-m.B*m.C) >= 1 {
+			return nil, fmt.Errorf("%s: map %d doesn't shrink the plane, its determinant must be between -1 and 1", name, i+1)
+		}
+		if m.weight() < 0 || math.IsNaN(m.weight()) {
+			return nil, fmt.Errorf("%s: map %d has a negative weight", name, i+1)
+		}
+		total += m.weight()
+	}
+	if total == 0 {
+		return nil, fmt.Errorf("%s: the maps' weights add to 0", name)
+	}
+
+	return maps, nil
+}
+
+// An ifs is a set of maps and the running total of their weights, for picking maps at random
+type ifs struct {
+	maps       []affineMap
+	cumulative []float64
+}
+
+func newIFSMaps(maps []affineMap) ifs {
+	var cumulative = make([]float64, len(maps))
+	var total float64
+	for k, m := range maps {
+		total += m.weight()
+		cumulative[k] = total
+	}
+	return ifs{maps, cumulative}
+}
+
+// play runs the chaos game for n points, calling visit with each point and the map that moved
+// it there
+func (s ifs) play(rng *rand.Rand, n int, visit func(x float64, y float64, k int)) {
+	var x, y float64
+	var total = s.cumulative[len(s.cumulative)-1]
+
+	for step := 0; step < ifsWarmup+n; step++ {
+		k := sort.SearchFloat64s(s.cumulative, rng.Float64()*total)
+		// Rounding can leave the draw past the last total
+		if k == len(s.maps) {
+			k--
+		}
+		x, y = s.maps[k].apply(x, y)
+		if step >= ifsWarmup {
+			visit(x, y, k)
+		}
+	}
+}
+
+// An ifsPlane represents the planar space of an IFS render, framed to fit the attractor
+type ifsPlane struct {
+	Plane
+	ifs
+}
+
+func newIFS() ifsPlane {
+	return ifsPlane{}
+}
+
+func (m *ifsPlane) process(c config) {
+	maps, err := loadIFS(c.ifs)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	m.ifs = newIFSMaps(maps)
+	if m.Plane, err = m.frame(c); err != nil {
+		fmt.Println(err)
+		return
+	}
+
+	if c.midX == -99.0 {
+		c.midX = (m.rMax + m.rMin) / 2.0
+	}
+
+	if c.midY == -99.0 {
+		c.midY = (m.iMax + m.iMin) / 2.0
+	}
+
+	if c.mode == imageMode {
+		m.image(c)
+	} else if c.mode == coordinatesMode {
+		var r, i = m.calculateCoordinatesAtPoint(c)
+		fmt.Printf("%18.17e, %18.17e\n", r, i)
+	}
+}
+
+// frame returns the plane around the attractor, with a margin, widened to the image's shape.
+// Maps that aren't contractive send points off to infinity, and maps that all share a fixed
+// point leave nothing to frame.
+func (m *ifsPlane) frame(c config) (Plane, error) {
+	var xMin, xMax, yMin, yMax = math.Inf(1), math.Inf(-1), math.Inf(1), math.Inf(-1)
+	m.play(rand.New(rand.NewSource(c.seed)), ifsBoundsPoints, func(x float64, y float64, k int) {
+		xMin, xMax = math.Min(xMin, x), math.Max(xMax, x)
+		yMin, yMax = math.Min(yMin, y), math.Max(yMax, y)
+	})
+
+	for _, bound := range []float64{xMin, xMax, yMin, yMax} {
+		if math.IsInf(bound, 0) || math.IsNaN(bound) {
+			return Plane{}, fmt.Errorf("ifs: the points run off to infinity, so the maps aren't contractive")
+		}
+	}
+	if xMax == xMin && yMax == yMin {
+		return Plane{}, fmt.Errorf("ifs: every point lands on %g, %g, so there is no attractor to frame", xMin, yMin)
+	}
+
+	var width = (xMax - xMin) * 1.1
+	var height = (yMax - yMin) * 1.1
+	var aspect = float64(c.width) / float64(c.height)
+	width, height = math.Max(width, height*aspect), math.Max(height, width/aspect)
+
+	var midX, midY = (xMax + xMin) / 2, (yMax + yMin) / 2
+	return Plane{midX - width/2, midX + width/2, midY - height/2, midY + height/2}, nil
+}
+
+// accumulate runs the chaos game across workers, each with its own random numbers seeded from
+// -seed so renders can be repeated, and counts the points each map puts in each pixel
+func (m *ifsPlane) accumulate(c config, samples int) [][]uint64 {
+	var histograms = make([][]uint64, len(m.maps))
+	for k := range histograms {
+		histograms[k] = make([]uint64, c.width*c.height)
+	}
+
+	const workers = 5
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+
+			rng := rand.New(rand.NewSource(c.seed + int64(w)))
+			n := samples / workers
+			if w < samples%workers {
+				n++
+			}
+
+			m.play(rng, n, func(x float64, y float64, k int) {
+				// y runs up the image, as the imaginary axis does for every plane
+				if px, py, ok := m.pixelAt(c, x, y); ok {
+					atomic.AddUint64(&histograms[k][py*c.width+px], 1)
+				}
+			})
+		}(w)
+	}
+	wg.Wait()
+
+	return histograms
+}
+
+func (m *ifsPlane) image(c config) {
+	if c.ifsColouring != densityIFSColouring && c.ifsColouring != mapIFSColouring {
+		fmt.Printf("ifs: unknown colouring %q, expected %s or %s\n", c.ifsColouring, densityIFSColouring, mapIFSColouring)
+		return
+	}
+
+	filters, err := parsePostProcessing(c.postProcessing)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+
+	var samples = c.samples
+	if samples <= 0 {
+		samples = c.width * c.height * 20
+	}
+
+	histograms := m.accumulate(c, samples)
+	gradient := initialiseGradient(c.gradient, c.gradientSpace)
+
+	var mapColours = make([]color.NRGBA, len(m.maps))
+	for k := range mapColours {
+		mapColours[k] = gradient.colourAt((float64(k) + 0.5) / float64(len(m.maps)))
+	}
+
+	var densities = make([]uint64, c.width*c.height)
+	var peak uint64
+	for i := range densities {
+		for _, h := range histograms {
+			densities[i] += h[i]
+		}
+		if densities[i] > peak {
+			peak = densities[i]
+		}
+	}
+
+	mbi := initialiseimage(c)
+	for y := 0; y < c.height; y++ {
+		for x := 0; x < c.width; x++ {
+			i := y*c.width + x
+			if densities[i] == 0 {
+				continue
+			}
+
+			level := math.Log1p(float64(densities[i])) / math.Log1p(float64(peak))
+			if c.ifsColouring == densityIFSColouring {
+				mbi.Set(x, y, gradient.colourAt(level))
+				continue
+			}
+
+			// Mix the colours of the maps by how many of the pixel's points each put there
+			var r, g, b float64
+			for k, h := range histograms {
+				share := float64(h[i]) / float64(densities[i])
+				r += share * float64(mapColours[k].R)
+				g += share * float64(mapColours[k].G)
+				b += share * float64(mapColours[k].B)
+			}
+			mbi.Set(x, y, color.NRGBA{uint8(r * level), uint8(g * level), uint8(b * level), 255})
+		}
+	}
+
+	postProcess(mbi, filters)
+
+	if c.filename == "" {
+		name := strings.TrimSuffix(filepath.Base(c.ifs), filepath.Ext(c.ifs))
+		c.filename = "ifs_" + name + "_" + strconv.FormatFloat(c.midX, 'E', -1, 64) + "_" + strconv.FormatFloat(c.midY, 'E', -1, 64) + "_" + strconv.FormatFloat(c.zoom, 'E', -1, 64) + ".jpg"
+	}
+
+	description := fmt.Sprintf("ifs=%s\nifsc=%s\nsamples=%d\nseed=%d\n", c.ifs, c.ifsColouring, samples, c.seed) + describeRender(c, gradient)
+	saveimage(mbi, c.output, c.filename, description)
+
+	fmt.Printf("%s/%s\n", c.output, c.filename)
+
+	saveSimulation(mbi, c, description)
+}
@@ -0,0 +1,142 @@
+package main
+
+import (
+	"io/ioutil"
+	"math"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestLoadIFSReadsMapsFromAFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "ifs")
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	defer os.RemoveAll(dir)
+
+	var file = filepath.Join(dir, "maps.json")
+	var maps = `[{"a": 0.5, "d": 0.5}, {"a": 0.5, "d": 0.25, "e": 0.5, "weight": 3}]`
+	if err = ioutil.WriteFile(file, []byte(maps), 0644); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	loaded, err := loadIFS(file)
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	if len(loaded) != 2 || loaded[1].E != 0.5 {
+		t.Errorf("Loaded %v.", loaded)
+	}
+	if loaded[0].weight() != 0.25 || loaded[1].weight() != 3 {
+		t.Errorf("Weights were %f and %f, want the first to default to its determinant, 0.25.", loaded[0].weight(), loaded[1].weight())
+	}
+
+	for _, bad := range []string{`[]`, `[{"a": 1, "weight": -1}]`, `[{"a": 1, "weight": 0}]`, `{"a": 1}`, `[{"a": 0.5, "d": 0.5}, {"a": 1.5, "d": 0.8}]`} {
+		if err = ioutil.WriteFile(file, []byte(bad), 0644); err != nil {
+			t.Fatalf("Unexpected error: %s", err)
+		}
+		if _, err := loadIFS(file); err == nil {
+			t.Errorf("Maps %s loaded, want an error.", bad)
+		}
+	}
+
+	if _, err := loadIFS(filepath.Join(dir, "missing.json")); err == nil {
+		t.Errorf("A missing file loaded, want an error.")
+	}
+}
+
+func TestChaosGameStaysOnTheSierpinskiTriangle(t *testing.T) {
+	maps, err := loadIFS("sierpinski")
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	var picked = make([]int, len(maps))
+	newIFSMaps(maps).play(rand.New(rand.NewSource(1)), 30000, func(x float64, y float64, k int) {
+		picked[k]++
+
+		// The triangle has corners (0, 0), (1, 0) and (0.5, sqrt(3)/2)
+		if y < -1e-9 || y > math.Sqrt(3)*x+1e-9 || y > math.Sqrt(3)*(1-x)+1e-9 {
+			t.Fatalf("(%f, %f) is outside the triangle.", x, y)
+		}
+	})
+
+	for k, n := range picked {
+		if n < 9000 || n > 11000 {
+			t.Errorf("Map %d was picked %d times of 30000, want about a third.", k+1, n)
+		}
+	}
+}
+
+func TestIFSFramesTheAttractor(t *testing.T) {
+	var c config
+	c.width, c.height = 400, 300
+	c.seed = 1
+
+	m := newIFS()
+	maps, _ := loadIFS("fern")
+	m.ifs = newIFSMaps(maps)
+	p, err := m.frame(c)
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	if math.Abs((p.rMax-p.rMin)/(p.iMax-p.iMin)-4.0/3.0) > 1e-9 {
+		t.Errorf("The plane %v isn't the shape of the image.", p)
+	}
+
+	// The fern grows from (0, 0) to about (2.6, 10)
+	if p.iMin > 0 || p.iMax < 10 || p.rMin > -2.2 || p.rMax < 2.7 {
+		t.Errorf("The plane %v doesn't hold the fern.", p)
+	}
+}
+
+func TestIFSWithoutAnAttractorIsntFramed(t *testing.T) {
+	var c config
+	c.width, c.height = 40, 30
+
+	// The first map stretches x fourfold without growing areas, and the two below share a fixed point
+	for _, maps := range [][]affineMap{
+		{{A: 4, D: 0.1, E: 1}, {A: 0.5, D: 0.5, F: 1}},
+		{{A: 0.5, D: 0.5}, {A: 0.25, D: 0.25}},
+	} {
+		m := newIFS()
+		m.ifs = newIFSMaps(maps)
+		if p, err := m.frame(c); err == nil {
+			t.Errorf("Maps %v were framed by %v, want an error.", maps, p)
+		}
+	}
+}
+
+func TestIFSIsRepeatable(t *testing.T) {
+	var c config
+	c.width, c.height = 40, 30
+	c.zoom = 1
+	c.seed = 3
+
+	m := newIFS()
+	maps, _ := loadIFS("dragon")
+	m.ifs = newIFSMaps(maps)
+	m.Plane, _ = m.frame(c)
+	c.midX, c.midY = (m.rMax+m.rMin)/2, (m.iMax+m.iMin)/2
+
+	first := m.accumulate(c, 5001)
+	second := m.accumulate(c, 5001)
+	if !reflect.DeepEqual(first, second) {
+		t.Errorf("Two renders with the same seed differed.")
+	}
+
+	var points uint64
+	for _, h := range first {
+		for _, count := range h {
+			points += count
+		}
+	}
+	if points != 5001 {
+		t.Errorf("%d points were plotted, want all 5001 in view.", points)
+	}
+}
@@ -1,9 +1,7 @@
 package main
 
 import (
-	"fmt"
 	"math"
-	"strconv"
 )
 
 // A Julia represents the strongly typed planar space for a Julia fractal
@@ -15,72 +13,50 @@ func newJulia() juliaPlane {
 	return juliaPlane{Plane{-2.0, 2.0, -2.0, 2.0}}
 }
 
+// process draws the Julia form of the Mandelbrot formula, z**2 + c
 func (m *juliaPlane) process(c config) {
-	if c.midX == -99.0 {
-		c.midX = (m.rMax + m.rMin) / 2.0
-	}
-
-	if c.midY == -99.0 {
-		c.midY = (m.iMax + m.iMin) / 2.0
-	}
+	var mandelbrot = newMandelbrot()
+	var f = mandelbrot.formula(c)
+	f.juliaPlane = m.Plane
 
-	if c.mode == imageMode {
-		m.image(c)
-	} else if c.mode == coordinatesMode {
-		var r, i = m.calculateCoordinatesAtPoint(c)
-		fmt.Printf("%18.17e, %18.17e\n", r, i)
-	}
+	c.julia = true
+	m.processFormula(c, f)
 }
 
-func (m *juliaPlane) image(c config) {
-	initialiseGradient(c.gradient)
-
-	c.bailout = determineJuliaBailout(c)
-
-	mbi := initialiseimage(c)
-
-	plottedChannel := make(chan PlottedPoint)
+// quadraticOrbit iterates z**2 + c from z
+func quadraticOrbit(zR float64, zI float64, cR float64, cI float64, bailout float64, config config) (bool, int, float64, float64) {
+	var iteration int
 
-	go func(points <-chan PlottedPoint) {
-		for p := range points {
-			if p.Escaped {
-				mbi.Set(p.X, p.Y, getPixelColour(p, c.maxIterations, c.colourMode))
-			}
-		}
-	}(plottedChannel)
-
-	var checkIfPointEscapes escapeCalculator = func(real float64, imag float64, config config) (bool, int, float64, float64) {
-		var iteration int
-		zR := real
-		zI := imag
-
-		for iteration = 0.0; zR*zR+zI*zI < config.bailout && iteration < config.maxIterations; iteration++ {
-			tmp := zR*zR - zI*zI
-			zI = 2*zR*zI + config.constI
-			zR = tmp + config.constR
-		}
-
-		return iteration < config.maxIterations, iteration, zR, zI
+	for iteration = 0.0; zR*zR+zI*zI < bailout && iteration < config.maxIterations; iteration++ {
+		tmp := zR*zR - zI*zI
+		zI = 2*zR*zI + cI
+		zR = tmp + cR
 	}
 
-	m.iterateOverPoints(c, plottedChannel, checkIfPointEscapes)
+	return iteration < config.maxIterations, iteration, zR, zI
+}
 
-	if c.filename == "" {
-		c.filename = "julia_" + strconv.FormatFloat(c.midX, 'E', -1, 64) + "_" + strconv.FormatFloat(c.midY, 'E', -1, 64) + "_" + strconv.FormatFloat(c.zoom, 'E', -1, 64) + ".jpg"
-	}
+// calculateJuliaDerivative follows the same orbit as quadraticOrbit from the point, tracking dz/dz0 alongside z
+func calculateJuliaDerivative(real float64, imag float64, config config) (float64, float64) {
+	zR, zI := real, imag
+	dR, dI := 1.0, 0.0
 
-	saveimage(mbi, c.output, c.filename)
+	for iteration := 0; zR*zR+zI*zI < config.bailout && iteration < config.maxIterations; iteration++ {
+		// dz = 2 * z * dz
+		dR, dI = 2*(zR*dR-zI*dI), 2*(zR*dI+zI*dR)
+		zR, zI = zR*zR-zI*zI+config.constR, 2*zR*zI+config.constI
+	}
 
-	fmt.Printf("%s/%s\n", c.output, c.filename)
+	return dR, dI
 }
 
-func determineJuliaBailout(config config) float64 {
+func determineJuliaBailout(constR float64, constI float64) float64 {
 	/* Where c is the constant in the Julia algorithim, expressed as a complex number,
 	Bailout should be R where R**2 - R = |c|.
 	That's the quadratic equation, which will give us two values. We'll take the larger.
 	*/
 
-	cAbs := math.Sqrt(config.constR*config.constR + config.constI*config.constI)
+	cAbs := math.Sqrt(constR*constR + constI*constI)
 
 	a, b := quadratic(1.0, -1.0, -1.0*cAbs)
 
@@ -0,0 +1,117 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"image/color"
+	"io/ioutil"
+	"math"
+)
+
+// Blend modes for compositing layers
+const (
+	normalBlend   = "normal"
+	multiplyBlend = "multiply"
+	screenBlend   = "screen"
+	overlayBlend  = "overlay"
+	addBlend      = "add"
+)
+
+// A layer is one colouring of the escape field, composited over the layers before it.
+// Layers are read from a JSON file given to -layers, e.g.
+// [{"colourMode": "smooth", "gradient": "fire"}, {"colourMode": "binary", "gradient": "ocean", "opacity": 0.4, "blend": "multiply"}]
+type layer struct {
+	ColourMode string   `json:"colourMode"` // Defaults to -c
+	Gradient   string   `json:"gradient"`   // Defaults to -g
+	Lighting   string   `json:"lighting"`   // Defaults to no lighting
+	Opacity    *float64 `json:"opacity"`    // Defaults to 1
+	Blend      string   `json:"blend"`      // Defaults to normal
+}
+
+// loadLayers reads the layers to render. Without a layers file the render is a single
+// layer built from the command line options.
+func loadLayers(c config) ([]layer, error) {
+	if c.layers == "" {
+		return []layer{{ColourMode: c.colourMode, Gradient: c.gradient, Lighting: c.lighting, Blend: normalBlend}}, nil
+	}
+
+	b, err := ioutil.ReadFile(c.layers)
+	if err != nil {
+		return nil, err
+	}
+
+	var layers []layer
+	if err = json.Unmarshal(b, &layers); err != nil {
+		return nil, fmt.Errorf("%s: %v", c.layers, err)
+	}
+
+	for i := range layers {
+		if layers[i].ColourMode == "" {
+			layers[i].ColourMode = c.colourMode
+		}
+		if layers[i].Gradient == "" {
+			layers[i].Gradient = c.gradient
+		}
+		if layers[i].Lighting == "" {
+			layers[i].Lighting = noLighting
+		}
+		if layers[i].Blend == "" {
+			layers[i].Blend = normalBlend
+		}
+
+		switch layers[i].Blend {
+		case normalBlend, multiplyBlend, screenBlend, overlayBlend, addBlend:
+		default:
+			return nil, fmt.Errorf("%s: layer %d has an unknown blend mode %q", c.layers, i+1, layers[i].Blend)
+		}
+	}
+
+	return layers, nil
+}
+
+// opacity returns the layer's opacity, which is fully opaque unless given
+func (l layer) opacity() float64 {
+	if l.Opacity == nil {
+		return 1.0
+	}
+	return clamp(*l.Opacity, 0, 1)
+}
+
+// configure returns the render config with the layer's own settings applied
+func (l layer) configure(c config) config {
+	c.colourMode = l.ColourMode
+	c.gradient = l.Gradient
+	c.lighting = l.Lighting
+	return c
+}
+
+// blend composites top over base, weighted by the layer's opacity and top's alpha
+func blend(mode string, base color.NRGBA, top color.NRGBA, opacity float64) color.NRGBA {
+	weight := opacity * float64(top.A) / 255
+
+	var channel = func(a uint8, b uint8) uint8 {
+		x, y := float64(a)/255, float64(b)/255
+
+		var blended float64
+		switch mode {
+		case multiplyBlend:
+			blended = x * y
+		case screenBlend:
+			blended = 1 - (1-x)*(1-y)
+		case overlayBlend:
+			if x < 0.5 {
+				blended = 2 * x * y
+			} else {
+				blended = 1 - 2*(1-x)*(1-y)
+			}
+		case addBlend:
+			blended = math.Min(1, x+y)
+		default:
+			blended = y
+		}
+
+		return uint8(math.Round(255 * clamp(x+(blended-x)*weight, 0, 1)))
+	}
+
+	return color.NRGBA{channel(base.R, top.R), channel(base.G, top.G), channel(base.B, top.B), base.A}
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"image/color"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestBlendModes(t *testing.T) {
+	// Channels of 0.2, 0.8 and 0.4 under 0.6, 0.4 and 1.0
+	base := color.NRGBA{51, 204, 102, 255}
+	top := color.NRGBA{153, 102, 255, 255}
+
+	cases := []struct {
+		mode    string
+		opacity float64
+		want    color.NRGBA
+	}{
+		{normalBlend, 1, color.NRGBA{153, 102, 255, 255}},
+		{multiplyBlend, 1, color.NRGBA{31, 82, 102, 255}},
+		{screenBlend, 1, color.NRGBA{173, 224, 255, 255}},
+		{overlayBlend, 1, color.NRGBA{61, 194, 204, 255}},
+		{addBlend, 1, color.NRGBA{204, 255, 255, 255}},
+		{multiplyBlend, 0, base},
+	}
+
+	for _, tc := range cases {
+		if got := blend(tc.mode, base, top, tc.opacity); got != tc.want {
+			t.Errorf("%s at opacity %.1f gave %v, want %v.", tc.mode, tc.opacity, got, tc.want)
+		}
+	}
+}
+
+func TestBlendWeighsByOpacityAndAlpha(t *testing.T) {
+	black := color.NRGBA{0, 0, 0, 255}
+
+	if got := blend(normalBlend, black, color.NRGBA{200, 100, 40, 255}, 0.5); got != (color.NRGBA{100, 50, 20, 255}) {
+		t.Errorf("Half opacity gave %v, want halfway to the top colour.", got)
+	}
+
+	if got := blend(screenBlend, black, color.NRGBA{200, 100, 40, 0}, 1); got != black {
+		t.Errorf("A transparent top gave %v, want the base unchanged.", got)
+	}
+}
+
+func TestLoadLayers(t *testing.T) {
+	dir, err := ioutil.TempDir("", "layers")
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	defer os.RemoveAll(dir)
+
+	var c config
+	c.colourMode = smoothColouring
+	c.gradient = "fire"
+	c.layers = filepath.Join(dir, "layers.json")
+
+	var write = func(content string) {
+		if err := ioutil.WriteFile(c.layers, []byte(content), 0644); err != nil {
+			t.Fatalf("Unexpected error: %s", err)
+		}
+	}
+
+	write(`[{}, {"colourMode": "binary", "gradient": "ocean", "opacity": 0.4, "blend": "multiply"}]`)
+	layers, err := loadLayers(c)
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	if len(layers) != 2 {
+		t.Fatalf("Loaded %d layers, want 2.", len(layers))
+	}
+	if layers[0].ColourMode != smoothColouring || layers[0].Gradient != "fire" || layers[0].Lighting != noLighting || layers[0].Blend != normalBlend || layers[0].opacity() != 1 {
+		t.Errorf("The first layer was %+v, want the command line's settings.", layers[0])
+	}
+	if layers[1].ColourMode != binaryColouring || layers[1].Gradient != "ocean" || layers[1].Blend != multiplyBlend || layers[1].opacity() != 0.4 {
+		t.Errorf("The second layer was %+v.", layers[1])
+	}
+
+	for _, bad := range []string{`[{"blend": "dodge"}]`, `[{"colourMode": }]`, `{"colourMode": "smooth"}`} {
+		write(bad)
+		if _, err := loadLayers(c); err == nil {
+			t.Errorf("Layers %s loaded, want an error.", bad)
+		}
+	}
+
+	c.layers = filepath.Join(dir, "missing.json")
+	if _, err := loadLayers(c); err == nil {
+		t.Errorf("A missing layers file loaded, want an error.")
+	}
+}
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"image/color"
+	"math"
+)
+
+const ( // Lighting models
+	noLighting      = "none"
+	lambertLighting = "lambert"
+	phongLighting   = "phong"
+)
+
+const ( // Sources of surface normals
+	slopeNormals    = "slope"    // Finite differences of the smooth iteration value of neighbouring pixels
+	analyticNormals = "analytic" // The derivative of z with respect to the pixel, where the algorithm provides one
+)
+
+const (
+	phongShininess = 32.0
+)
+
+// A light shines on the escape field treated as a surface, lit from a single direction
+type light struct {
+	model     string
+	direction [3]float64 // Unit vector towards the light
+	halfway   [3]float64 // Unit vector halfway between the light and the viewer, for Blinn-Phong highlights
+	ambient   float64
+	specular  float64
+}
+
+func newLight(c config) light {
+	angle := c.lightAngle * math.Pi / 180.0
+	elevation := c.lightElevation * math.Pi / 180.0
+
+	direction := [3]float64{math.Cos(elevation) * math.Cos(angle), math.Cos(elevation) * math.Sin(angle), math.Sin(elevation)}
+	halfway := normalise([3]float64{direction[0], direction[1], direction[2] + 1.0})
+
+	return light{c.lighting, direction, halfway, clamp(c.lightAmbient, 0, 1), clamp(c.lightSpecular, 0, 1)}
+}
+
+// illuminate multiplies a colour by the diffuse light falling on a surface with the given
+// normal, adding a white highlight under Blinn-Phong lighting.
+func (l light) illuminate(colour color.NRGBA, normal [3]float64) color.NRGBA {
+	diffuse := max(0, dot(normal, l.direction))
+	shade := l.ambient + (1-l.ambient)*diffuse
+
+	var highlight = 0.0
+	if l.model == phongLighting {
+		highlight = 255 * l.specular * math.Pow(max(0, dot(normal, l.halfway)), phongShininess)
+	}
+
+	var channel = func(v uint8) uint8 {
+		return uint8(clamp(float64(v)*shade+highlight, 0, 255))
+	}
+
+	return color.NRGBA{channel(colour.R), channel(colour.G), channel(colour.B), colour.A}
+}
+
+// surfaceNormal finds the normal of the escape field at a point, either analytically from
+// the fractal's derivative or from the slope of the smooth iteration values around it.
+func (p *Plane) surfaceNormal(c config, f escapeTimeFractal, field *escapeField, point PlottedPoint, s smoothing) [3]float64 {
+	if c.normals == analyticNormals && f.derivative != nil {
+		r, i := p.coordinatesAt(c, point.X, point.Y)
+		dr, di := f.derivative(r, i, c)
+
+		// u = z / dz points away from the set, along the direction the surface falls
+		denominator := dr*dr + di*di
+		if denominator > 0 && !math.IsInf(denominator, 0) {
+			ur := (point.real*dr + point.imag*di) / denominator
+			ui := (point.imag*dr - point.real*di) / denominator
+			magnitude := math.Hypot(ur, ui)
+			if magnitude > 0 && !math.IsNaN(magnitude) {
+				return normalise([3]float64{ur / magnitude, ui / magnitude, 1.0 / c.lightHeight})
+			}
+		}
+	}
+
+	left := surfaceHeight(field.at(point.X-1, point.Y), point, c, s)
+	right := surfaceHeight(field.at(point.X+1, point.Y), point, c, s)
+	up := surfaceHeight(field.at(point.X, point.Y-1), point, c, s)
+	down := surfaceHeight(field.at(point.X, point.Y+1), point, c, s)
+
+	// Pixel rows run downwards while the imaginary axis runs upwards
+	return normalise([3]float64{-(right - left) / 2.0, -(up - down) / 2.0, 1.0})
+}
+
+// surfaceHeight is the height of the surface at a neighbour of centre. Neighbours that
+// didn't escape take the height of the centre so the set's edge doesn't read as a cliff.
+func surfaceHeight(neighbour PlottedPoint, centre PlottedPoint, c config, s smoothing) float64 {
+	if !neighbour.Escaped {
+		neighbour = centre
+	}
+	return jitter(neighbour, s) * c.lightHeight
+}
+
+func dot(a [3]float64, b [3]float64) float64 {
+	return a[0]*b[0] + a[1]*b[1] + a[2]*b[2]
+}
+
+func normalise(v [3]float64) [3]float64 {
+	length := math.Sqrt(dot(v, v))
+	if length == 0 {
+		return v
+	}
+	return [3]float64{v[0] / length, v[1] / length, v[2] / length}
+}
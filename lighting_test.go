@@ -0,0 +1,116 @@
+package main
+
+import (
+	"image/color"
+	"math"
+	"testing"
+)
+
+// slopedField returns a field whose heights are the given function of the pixel
+func slopedField(width int, height int, h func(x int, y int) int) *escapeField {
+	field := &escapeField{width: width, height: height, points: make([]PlottedPoint, width*height)}
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			field.points[y*width+x] = PlottedPoint{X: x, Y: y, Iterations: h(x, y), Escaped: true}
+		}
+	}
+	return field
+}
+
+func TestFlatFieldIsEvenlyLit(t *testing.T) {
+	var c config
+	c.normals = slopeNormals
+	c.lightHeight = 1.0
+	c.lighting = lambertLighting
+	c.lightAngle, c.lightElevation, c.lightAmbient = 45, 30, 0.2
+
+	var p Plane
+	field := slopedField(5, 5, func(x int, y int) int { return 7 })
+	l := newLight(c)
+
+	// Lit from 30 degrees up, a flat surface catches sin 30 = 0.5 of the light
+	want := 200 * (0.2 + 0.8*0.5)
+	for _, point := range field.points {
+		normal := p.surfaceNormal(c, escapeTimeFractal{}, field, point, smoothing{})
+		if normal != [3]float64{0, 0, 1} {
+			t.Fatalf("Normal at %d, %d was %v, want straight up.", point.X, point.Y, normal)
+		}
+
+		if got := l.illuminate(color.NRGBA{200, 200, 200, 255}, normal); math.Abs(float64(got.R)-want) > 1 {
+			t.Fatalf("Shade at %d, %d was %d, want %.0f.", point.X, point.Y, got.R, want)
+		}
+	}
+}
+
+func TestSlopeGivesItsNormal(t *testing.T) {
+	var c config
+	c.normals = slopeNormals
+	c.lightHeight = 1.0
+
+	var p Plane
+
+	// Rising one step a pixel to the right, the surface faces left and up
+	field := slopedField(5, 5, func(x int, y int) int { return x })
+	normal := p.surfaceNormal(c, escapeTimeFractal{}, field, field.at(2, 2), smoothing{})
+	want := normalise([3]float64{-1, 0, 1})
+	if !closeNormals(normal, want) {
+		t.Errorf("Normal was %v, want %v.", normal, want)
+	}
+
+	// Rising two steps a pixel down the image, towards the negative imaginary axis
+	field = slopedField(5, 5, func(x int, y int) int { return 2 * y })
+	normal = p.surfaceNormal(c, escapeTimeFractal{}, field, field.at(2, 2), smoothing{})
+	want = normalise([3]float64{0, 2, 1})
+	if !closeNormals(normal, want) {
+		t.Errorf("Normal was %v, want %v.", normal, want)
+	}
+}
+
+func TestAnalyticNormalsFollowTheSlopeOffAxis(t *testing.T) {
+	var c config
+	c.width, c.height = 60, 40
+	c.zoom = 1
+	c.midX, c.midY = -0.5, 0.3
+	c.maxIterations = 200
+	c.bailout = 2.0
+	c.lightHeight = 1.0
+
+	m := newMandelbrot()
+	f := m.formula(c).mandelbrotForm()
+	field := m.plotField(c, f.plotter())
+	s := f.estimateSmoothing(field)
+
+	var agreement float64
+	var count int
+	for _, point := range field.points {
+		// Stay clear of the edges of the image and of the set, where the slope is cut short
+		if point.X < 2 || point.Y < 2 || point.X >= c.width-2 || point.Y >= c.height-2 || !point.Escaped || point.Iterations > 20 {
+			continue
+		}
+
+		c.normals = analyticNormals
+		analytic := m.surfaceNormal(c, f, field, point, s)
+		c.normals = slopeNormals
+		slope := m.surfaceNormal(c, f, field, point, s)
+
+		// Compare only the directions the surface falls in
+		a := math.Hypot(analytic[0], analytic[1])
+		b := math.Hypot(slope[0], slope[1])
+		if a == 0 || b == 0 {
+			continue
+		}
+		agreement += (analytic[0]*slope[0] + analytic[1]*slope[1]) / (a * b)
+		count++
+	}
+
+	if count == 0 {
+		t.Fatalf("No points were compared.")
+	}
+	if mean := agreement / float64(count); mean < 0.9 {
+		t.Errorf("Analytic and slope normals agreed by %f on average over %d points, want close to 1.", mean, count)
+	}
+}
+
+func closeNormals(a [3]float64, b [3]float64) bool {
+	return math.Abs(a[0]-b[0]) < 1e-9 && math.Abs(a[1]-b[1]) < 1e-9 && math.Abs(a[2]-b[2]) < 1e-9
+}
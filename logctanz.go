@@ -4,10 +4,7 @@ package main
 // Don't think it is quite succesful
 // Best called with iterations turned down to 100
 
-
 import (
-	"fmt"
-	"strconv"
 	"math/cmplx"
 )
 
@@ -21,58 +18,30 @@ func newLogTan() LogTanPlane {
 }
 
 func (m *LogTanPlane) process(c config) {
-	if c.midX == -99.0 {
-		c.midX = (m.rMax + m.rMin) / 2.0
-	}
-
-	if c.midY == -99.0 {
-		c.midY = (m.iMax + m.iMin) / 2.0
-	}
-
-	if c.mode == imageMode {
-		m.image(c)
-	} else if c.mode == coordinatesMode {
-		var r, i = m.calculateCoordinatesAtPoint(c)
-		fmt.Printf("%18.17e, %18.17e\n", r, i)
-	}
+	m.processFormula(c, m.formula())
 }
 
-func (m *LogTanPlane) image(c config) {
-	initialiseGradient(c.gradient)
-
-	mbi := initialiseimage(c)
-
-	plottedChannel := make(chan PlottedPoint)
-
-	go func(points <-chan PlottedPoint) {
-		for p := range points {
-			if p.Escaped {
-				mbi.Set(p.X, p.Y, getPixelColour(p, c.maxIterations, c.colourMode))
-			}
-		}
-	}(plottedChannel)
-
-	m.iterateOverPoints(c, plottedChannel, m.calculateEscape)
-
-	if c.filename == "" {
-		c.filename = "logtan_" + strconv.FormatFloat(c.midX, 'E', -1, 64) + "_" + strconv.FormatFloat(c.midY, 'E', -1, 64) + "_" + strconv.FormatFloat(c.zoom, 'E', -1, 64) + ".jpg"
+func (m *LogTanPlane) formula() formula {
+	return formula{
+		name:    "logtan_",
+		orbit:   logTanOrbit,
+		start:   startAtC,
+		bailout: 1000.0,
+		// The escape test isn't on the modulus, so the Julia form keeps the same one
+		juliaBailout: func(cReal float64, cImag float64) float64 { return 1000.0 },
+		juliaPlane:   Plane{-2.0, 2.0, -2.0, 2.0},
 	}
-
-	saveimage(mbi, c.output, c.filename)
-
-	fmt.Printf("%s/%s\n", c.output, c.filename)
 }
 
-func (m *LogTanPlane) calculateEscape(r float64, i float64, config config) (bool, int, float64, float64) {
+func logTanOrbit(zr float64, zi float64, r float64, i float64, bailout float64, config config) (bool, int, float64, float64) {
 	//z := complex(1.0, 0.0)
-	z := complex(r, i)
+	z := complex(zr, zi)
 	c := complex(r, i)
-	
+
 	var iteration int
 
-	var bailout = 1000.0
-	for iteration = 1; imag(z) * real(z) <= bailout && iteration < config.maxIterations; iteration++ {
-		z = z * cmplx.Log(c) * cmplx.Tan(z) + c
+	for iteration = 1; imag(z)*real(z) <= bailout && iteration < config.maxIterations; iteration++ {
+		z = z*cmplx.Log(c)*cmplx.Tan(z) + c
 	}
 
 	return iteration < config.maxIterations, iteration, real(z), imag(z)
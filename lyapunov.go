@@ -0,0 +1,175 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// A lyapunovPlane represents the strongly typed planar space for Lyapunov fractals, where the
+// real axis is a and the imaginary axis is b
+type lyapunovPlane struct {
+	Plane
+}
+
+func newLyapunov() lyapunovPlane {
+	return lyapunovPlane{Plane{2.0, 4.0, 2.0, 4.0}}
+}
+
+func (m *lyapunovPlane) process(c config) {
+	if c.midX == -99.0 {
+		c.midX = (m.rMax + m.rMin) / 2.0
+	}
+
+	if c.midY == -99.0 {
+		c.midY = (m.iMax + m.iMin) / 2.0
+	}
+
+	if c.mode == imageMode {
+		m.image(c)
+	} else if c.mode == coordinatesMode {
+		var r, i = m.calculateCoordinatesAtPoint(c)
+		fmt.Printf("%18.17e, %18.17e\n", r, i)
+	}
+}
+
+func (m *lyapunovPlane) image(c config) {
+	sequence, err := parseLyapunovSequence(c.sequence)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+
+	filters, err := parsePostProcessing(c.postProcessing)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+
+	stable := initialiseGradient(c.gradient, c.gradientSpace)
+	var chaotic *Gradient
+	if c.chaoticGradient != "" {
+		g := initialiseGradient(c.chaoticGradient, c.gradientSpace)
+		chaotic = &g
+	}
+
+	// The exponent of each point is kept in its real part. Chaotic points, with positive
+	// exponents, count as escaped.
+	var plot pointPlotter = func(p Point, config config) PlottedPoint {
+		exponent := lyapunovExponent(p.real, p.imag, sequence, config)
+		return PlottedPoint{X: p.X, Y: p.Y, real: exponent, Iterations: config.maxIterations, Escaped: exponent > 0}
+	}
+
+	field := m.plotField(c, plot)
+	stableRange, chaoticRange := lyapunovRanges(field)
+
+	mbi := initialiseimage(c)
+	for _, point := range field.points {
+		exponent := point.real
+		if math.IsNaN(exponent) || math.IsInf(exponent, 0) {
+			continue
+		}
+
+		if exponent > 0 {
+			if chaotic != nil {
+				mbi.Set(point.X, point.Y, chaotic.colourAt(exponent/chaoticRange))
+			}
+		} else {
+			mbi.Set(point.X, point.Y, stable.colourAt(exponent/stableRange))
+		}
+	}
+
+	postProcess(mbi, filters)
+
+	if c.filename == "" {
+		c.filename = "lyapunov_" + c.sequence + "_" + strconv.FormatFloat(c.midX, 'E', -1, 64) + "_" + strconv.FormatFloat(c.midY, 'E', -1, 64) + "_" + strconv.FormatFloat(c.zoom, 'E', -1, 64) + ".jpg"
+	}
+
+	description := fmt.Sprintf("seq=%s\nwarmup=%d\n", c.sequence, c.warmup) + describeRender(c, stable)
+	if chaotic != nil {
+		description += fmt.Sprintf("cg=%s\n", formatGradient(chaotic.stops))
+	}
+	saveimage(mbi, c.output, c.filename, description)
+
+	fmt.Printf("%s/%s\n", c.output, c.filename)
+
+	saveSimulation(mbi, c, description)
+}
+
+// parseLyapunovSequence reads a sequence of As and Bs, such as AABAB, as which of a and b
+// drives each step of the logistic map
+func parseLyapunovSequence(str string) ([]bool, error) {
+	var sequence []bool
+	for _, r := range strings.ToUpper(str) {
+		if r != 'A' && r != 'B' {
+			return nil, fmt.Errorf("lyapunov: the sequence may only hold A and B, got %q", r)
+		}
+		sequence = append(sequence, r == 'B')
+	}
+
+	if len(sequence) == 0 {
+		return nil, fmt.Errorf("lyapunov: the sequence needs at least one A or B")
+	}
+	return sequence, nil
+}
+
+// lyapunovExponent follows the logistic map x = r * x * (1 - x) from x = 0.5, with r taking a
+// or b as the sequence says. After the warm up it averages log |r * (1 - 2x)|, the rate at
+// which neighbouring orbits separate, over the configured iterations.
+func lyapunovExponent(a float64, b float64, sequence []bool, config config) float64 {
+	var x = 0.5
+	var sum float64
+	var step int
+
+	for n := 0; n < config.warmup; n++ {
+		r := a
+		if sequence[step] {
+			r = b
+		}
+		x = r * x * (1 - x)
+		step = (step + 1) % len(sequence)
+	}
+
+	for n := 0; n < config.maxIterations; n++ {
+		r := a
+		if sequence[step] {
+			r = b
+		}
+		// A derivative of exactly 0 would take the log to -Inf
+		sum += math.Log(math.Max(1e-12, math.Abs(r*(1-2*x))))
+		x = r * x * (1 - x)
+		step = (step + 1) % len(sequence)
+	}
+
+	return sum / max(1, float64(config.maxIterations))
+}
+
+// lyapunovRanges returns the exponents that map to the ends of the stable and chaotic
+// gradients. A few extreme points would wash out the rest, so each is taken at the 99th
+// percentile of its side of 0.
+func lyapunovRanges(field *escapeField) (float64, float64) {
+	var stable, chaotic []float64
+	for _, p := range field.points {
+		if math.IsNaN(p.real) || math.IsInf(p.real, 0) {
+			continue
+		}
+		if p.real > 0 {
+			chaotic = append(chaotic, p.real)
+		} else if p.real < 0 {
+			stable = append(stable, p.real)
+		}
+	}
+
+	var stableRange, chaoticRange = -1.0, 1.0
+	if len(stable) > 0 {
+		sort.Float64s(stable)
+		stableRange = stable[len(stable)/100]
+	}
+	if len(chaotic) > 0 {
+		sort.Float64s(chaotic)
+		chaoticRange = chaotic[len(chaotic)*99/100]
+	}
+	return stableRange, chaoticRange
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"math"
+	"testing"
+)
+
+func TestLyapunovExponentOfTheLogisticMap(t *testing.T) {
+	var c config
+	c.maxIterations = 20000
+	c.warmup = 100
+	sequence, _ := parseLyapunovSequence("A")
+
+	// Chaotic at r = 3.9, where the exponent is close to 0.5
+	if got := lyapunovExponent(3.9, 0.0, sequence, c); math.Abs(got-0.5) > 0.05 {
+		t.Errorf("Exponent at r = 3.9 was %f, want about 0.5.", got)
+	}
+
+	// Superstable at r = 2, where the orbit sits on the peak of the map
+	if got := lyapunovExponent(2.0, 0.0, sequence, c); got > -10 {
+		t.Errorf("Exponent at r = 2 was %f, want it far below 0.", got)
+	}
+
+	// A stable period two cycle at r = 3.2
+	if got := lyapunovExponent(3.2, 0.0, sequence, c); got >= 0 {
+		t.Errorf("Exponent at r = 3.2 was %f, want it negative.", got)
+	}
+}
+
+func TestLyapunovSequenceAlternates(t *testing.T) {
+	var c config
+	c.maxIterations = 1000
+	c.warmup = 100
+
+	ab, _ := parseLyapunovSequence("ab")
+	ba, _ := parseLyapunovSequence("BA")
+	a, _ := parseLyapunovSequence("A")
+
+	// With a = b the sequence makes no difference
+	if lyapunovExponent(3.5, 3.5, ab, c) != lyapunovExponent(3.5, 0.0, a, c) {
+		t.Errorf("Sequence AB with a = b differed from A.")
+	}
+
+	if lyapunovExponent(3.2, 3.9, ab, c) == lyapunovExponent(3.2, 3.9, ba, c) && lyapunovExponent(3.2, 3.9, ab, c) == lyapunovExponent(3.2, 3.2, a, c) {
+		t.Errorf("Sequence AB ignored b.")
+	}
+
+	if _, err := parseLyapunovSequence("AXB"); err == nil {
+		t.Errorf("Sequence AXB parsed, want an error.")
+	}
+}
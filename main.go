@@ -1,12 +1,14 @@
 package main
 
 import (
+	"bytes"
 	"flag"
 	"fmt"
 	"image"
 	"image/color"
 	"image/draw"
 	"image/jpeg"
+	"math"
 	"os"
 	"strings"
 	"sync"
@@ -18,40 +20,96 @@ const (
 
 const (
 	// Modes
-	imageMode       = "image"
-	coordinatesMode = "coordsAt"
+	imageMode           = "image"
+	coordinatesMode     = "coordsAt"
+	extractGradientMode = "extractGradient"
+	swatchMode          = "swatch"
 )
 
 // Fractals supported
 const (
 	mutantMandelbrotAlgoValue = "mutant_mandelbrot"
 	mandelbrotAlgoValue       = "mandelbrot"
-	burningShipAlgoValue      = "ship"
 	juliaAlgoValue            = "julia"
 	z1ZcZIAlgoValue           = "z1zczi"
 	boujeeAlgoValue           = "boujee"
 	logTanAlgoValue           = "logtan"
 	sharkFinAlgoValue         = "sharkfin"
+	multibrotAlgoValue        = "multibrot"
+	multiJuliaAlgoValue       = "multijulia"
+	newtonAlgoValue           = "newton"
+	novaAlgoValue             = "nova"
+	phoenixAlgoValue          = "phoenix"
+	expressionAlgoValue       = "formula"
+	lyapunovAlgoValue         = "lyapunov"
+	buddhabrotAlgoValue       = "buddhabrot"
+	nebulabrotAlgoValue       = "nebulabrot"
+	ifsAlgoValue              = "ifs"
 )
 
 type config struct {
-	algorithm     string  // Which algorithm to use
-	maxIterations int     // How many iterations to allow before giving up and treating as escaped
-	bailout       float64 // Bailout point after which a point is considered to have escaped. Overriden for Julia
-	width         int     // Width in pixels of the output image
-	height        int     // Height in pixels of the output image
-	pointX        int     // X coordinate of a pixel being scaled to the complex plane
-	pointY        int     // Y coordinate of a pixel being scaled to the complex plane
-	midX          float64 // Real component of the complex number being used as the centre of the plot
-	midY          float64 // Imaginary component of the complex number being used as the centre of the plot
-	zoom          float64 // Zoom level of the plot
-	output        string  // Path to output image to
-	filename      string  // Name of the output image
-	gradient      string  // Gradient to use for colouring. Ignored when using noColour mode
-	mode          string  // Render an image or calculate coordinates
-	colourMode    string  // Colour mode of the image
-	constR        float64 // Real component of the constant in a Julia plot
-	constI        float64 // Imaginary component of the constant in a Julia Plot
+	algorithm        string  // Which algorithm to use
+	maxIterations    int     // How many iterations to allow before giving up and treating as escaped
+	bailout          float64 // Bailout point after which a point is considered to have escaped. Overriden for Julia
+	width            int     // Width in pixels of the output image
+	height           int     // Height in pixels of the output image
+	pointX           int     // X coordinate of a pixel being scaled to the complex plane
+	pointY           int     // Y coordinate of a pixel being scaled to the complex plane
+	midX             float64 // Real component of the complex number being used as the centre of the plot
+	midY             float64 // Imaginary component of the complex number being used as the centre of the plot
+	zoom             float64 // Zoom level of the plot
+	output           string  // Path to output image to
+	filename         string  // Name of the output image
+	gradient         string  // Gradient to use for colouring. Ignored when using noColour mode
+	interiorGradient string  // Gradient to use for points that did not escape. Left black when empty
+	gradientSpace    string  // Colour space gradients are interpolated in
+	paletteLength    int     // Number of palette entries sampled from the gradient for smooth and banded colouring
+	paletteOffset    float64 // Phase offset into the palette, in palette entries
+	paletteRepeat    int     // Number of times the gradient repeats across the palette
+	colourDensity    float64 // Scale applied to escape values before they index the palette
+	autoFit          bool    // Fit the gradient domain to the escape counts found in the render
+	fitLow           float64 // Percentile of escape counts fitted to the start of the gradient
+	fitHigh          float64 // Percentile of escape counts fitted to the end of the gradient
+	gradientMin      int     // Escape count pinned to the start of the gradient, unless 0
+	gradientMax      int     // Escape count pinned to the end of the gradient, unless 0
+	lighting         string  // Lighting model used to shade the escape field
+	normals          string  // How surface normals are found for lighting
+	lightAngle       float64 // Direction the light comes from, in degrees anticlockwise from the positive real axis
+	lightElevation   float64 // Height of the light above the plane, in degrees
+	lightAmbient     float64 // Proportion of light that reaches every point regardless of its normal
+	lightSpecular    float64 // Strength of specular highlights under Blinn-Phong lighting
+	lightHeight      float64 // Scale of the height field built from smooth iteration values
+	mode             string  // Render an image or calculate coordinates
+	colourMode       string  // Colour mode of the image
+	constR           float64 // Real component of the constant in a Julia plot
+	constI           float64 // Imaginary component of the constant in a Julia Plot
+	exponent         float64 // Exponent n of z**n + c in Multibrot and Multi-Julia plots, and of z**n in Phoenix plots
+	julia            bool    // Draw the Julia form of the formula, with c fixed at constR + constI i
+	slice            string  // Plane through (c, z0) space to draw, as origin;u;v
+	polynomial       string  // Coefficients of the polynomial in Newton and Nova plots, highest power first
+	roots            string  // Roots of the polynomial in Newton and Nova plots, used in place of its coefficients
+	relaxation       float64 // Fraction of each Newton step to take, in Newton and Nova plots
+	expression       string  // Formula plotted by the formula algorithm, in terms of z, c, pixel and params
+	start            string  // Starting z of the formula algorithm's Mandelbrot form
+	escapeCondition  string  // Condition on z for the formula algorithm to treat a point as escaped
+	params           string  // Named params of the formula algorithm, as name=value,...
+	sequence         string  // Sequence of As and Bs driving the logistic map in a Lyapunov plot
+	warmup           int     // Iterations of the logistic map to discard before measuring the Lyapunov exponent
+	chaoticGradient  string  // Gradient for the chaotic regions of a Lyapunov plot. Left black when empty
+	samples          int     // Values of c sampled by a Buddhabrot render. Ten per pixel when 0
+	seed             int64   // Seed of the random numbers sampling c in a Buddhabrot render
+	limits           string  // Iteration limits of the red, green and blue channels of a Nebulabrot render
+	anti             bool    // Whether a Buddhabrot render plots the orbits that don't escape
+	ifs              string  // Preset, or path of a JSON file, giving the maps of an IFS render
+	ifsColouring     string  // How an IFS render is coloured, by density or by map
+	layers           string  // Path of a JSON file listing layers to composite, see layer
+	postProcessing   string  // Chain of filters applied to the finished image, see parsePostProcessing
+	simulate         string  // Colour vision deficiency to save a simulated preview for, if any
+	simulateSeverity float64 // Severity of the simulated deficiency, from 0 for none to 1 for dichromacy
+	source           string  // Path of a reference image to extract a gradient from
+	sampling         string  // How colours are sampled from the reference image
+	sampleLine       string  // Line across the reference image to sample, in fractions of its size
+	stops            int     // Number of stops to extract from the reference image
 }
 
 // A Plane represents the base confines of the complex plane for a fractal based
@@ -79,12 +137,26 @@ type PlottedPoint struct {
 	real       float64 // The real component of final value of z in the escape time calculation
 	imag       float64 // The imaginary component of final value of z in the escape time calculation
 	Iterations int     // The number of iterations it took to determine a result
-	Escaped    bool    // True if the coordinate escaped the escape time function
+	Escaped    bool    // True if the coordinate escaped the escape time function, or settled in a convergence test
+	Root       int     // Index of the root a convergence test settled on
 }
 
 func main() {
 	c := getConfig()
 
+	if err := checkColourVision(c.simulate, c.simulateSeverity); err != nil {
+		fmt.Println(err)
+		return
+	}
+
+	if c.mode == extractGradientMode {
+		extractGradient(c)
+		return
+	} else if c.mode == swatchMode {
+		renderSwatch(c)
+		return
+	}
+
 	if c.algorithm == mandelbrotAlgoValue {
 		m := newMandelbrot()
 		m.process(c)
@@ -92,10 +164,9 @@ func main() {
 		m := newMutantMandelbrot()
 
 		m.process(c)
-	} else if c.algorithm == burningShipAlgoValue {
-		b := newBurningShip()
-
-		b.process(c)
+	} else if v, ok := absVariants[c.algorithm]; ok {
+		o := newAbsVariant(v)
+		o.process(c)
 	} else if c.algorithm == juliaAlgoValue {
 		j := newJulia()
 		j.process(c)
@@ -111,39 +182,119 @@ func main() {
 	} else if c.algorithm == sharkFinAlgoValue {
 		o := newSharkFin()
 		o.process(c)
+	} else if c.algorithm == multibrotAlgoValue {
+		o := newMultibrot(c.exponent)
+		o.process(c)
+	} else if c.algorithm == multiJuliaAlgoValue {
+		o := newMultiJulia(c.exponent)
+		o.process(c)
+	} else if c.algorithm == newtonAlgoValue {
+		o := newNewton()
+		o.process(c)
+	} else if c.algorithm == novaAlgoValue {
+		o := newNova()
+		o.process(c)
+	} else if c.algorithm == phoenixAlgoValue {
+		o := newPhoenix()
+		o.process(c)
+	} else if c.algorithm == expressionAlgoValue {
+		o := newExpression()
+		o.process(c)
+	} else if c.algorithm == lyapunovAlgoValue {
+		o := newLyapunov()
+		o.process(c)
+	} else if c.algorithm == buddhabrotAlgoValue || c.algorithm == nebulabrotAlgoValue {
+		o := newBuddhabrot()
+		o.process(c)
+	} else if c.algorithm == ifsAlgoValue {
+		o := newIFS()
+		o.process(c)
 	}
 
 }
 
 func getConfig() config {
 	var c config
-
-	var supportedAlgorithms = []string{mandelbrotAlgoValue, juliaAlgoValue, burningShipAlgoValue, mutantMandelbrotAlgoValue, z1ZcZIAlgoValue, boujeeAlgoValue, logTanAlgoValue, sharkFinAlgoValue}
-	var supportedColourings = []string{trueColouring, bandedColouring, smoothColouring, noColouring}
-	var supportedModes = []string{imageMode, coordinatesMode}
-
-	flag.StringVar(&c.algorithm, "a", "mandelbrot", "Fractal algorithm: "+strings.Join(supportedAlgorithms, ", "))
-	flag.Float64Var(&c.midX, "r", -99.0, "Real component of the midpoint.")
-	flag.Float64Var(&c.midY, "i", -99.0, "Imaginary component of the midpoint.")
-	flag.Float64Var(&c.zoom, "z", 1, "Zoom level.")
-	flag.StringVar(&c.output, "o", ".", "Output path.")
-	flag.StringVar(&c.filename, "f", "", "Output file name.")
-	flag.StringVar(&c.colourMode, "c", "none", "Colour mode: "+strings.Join(supportedColourings, ", "))
-	flag.Float64Var(&c.bailout, "b", 4.0, "Bailout value.")
-	flag.IntVar(&c.width, "w", 1600, "Width of render.")
-	flag.IntVar(&c.height, "h", 1600, "Height of render.")
-	flag.IntVar(&c.maxIterations, "m", 2000, "Maximum Iterations before giving up on finding an escape.")
-	flag.StringVar(&c.gradient, "g", defaultGradient, "Gradient to use.")
-	flag.StringVar(&c.mode, "mode", "image", "Mode:  "+strings.Join(supportedModes, ", "))
-	flag.IntVar(&c.pointX, "x", 0, "x cordinate of a pixel, used for translating to the real component. 0,0 is top left.")
-	flag.IntVar(&c.pointY, "y", 0, "y cordinate of a pixel, used for translating to the real component. 0,0 is top left.")
-	flag.Float64Var(&c.constR, "cr", 0.0, "Real component of the const point in a Julia set.")
-	flag.Float64Var(&c.constI, "ci", 0.0, "Imaginary component of the const point in a Julia set.")
+	defineFlags(flag.CommandLine, &c)
 	flag.Parse()
 
 	return c
 }
 
+// defineFlags registers the command line options on a flag set, storing them in c
+func defineFlags(flags *flag.FlagSet, c *config) {
+	var supportedAlgorithms = []string{mandelbrotAlgoValue, juliaAlgoValue, mutantMandelbrotAlgoValue, z1ZcZIAlgoValue, boujeeAlgoValue, logTanAlgoValue, sharkFinAlgoValue, multibrotAlgoValue, multiJuliaAlgoValue, newtonAlgoValue, novaAlgoValue, phoenixAlgoValue, expressionAlgoValue, lyapunovAlgoValue, buddhabrotAlgoValue, nebulabrotAlgoValue, ifsAlgoValue}
+	supportedAlgorithms = append(supportedAlgorithms, absVariantNames()...)
+	var supportedGradientSpaces = []string{rgbInterpolation, linearInterpolation, oklabInterpolation}
+	var supportedLighting = []string{noLighting, lambertLighting, phongLighting}
+	var supportedNormals = []string{slopeNormals, analyticNormals}
+	var supportedModes = []string{imageMode, coordinatesMode, extractGradientMode, swatchMode}
+	var supportedSamplings = []string{kmeansSampling, lineSampling}
+
+	flags.StringVar(&c.algorithm, "a", "mandelbrot", "Fractal algorithm: "+strings.Join(supportedAlgorithms, ", "))
+	flags.Float64Var(&c.midX, "r", -99.0, "Real component of the midpoint.")
+	flags.Float64Var(&c.midY, "i", -99.0, "Imaginary component of the midpoint. The Burning Ship is drawn upright, so negate the imaginary parts of its coordinates from references that draw it upside down.")
+	flags.Float64Var(&c.zoom, "z", 1, "Zoom level.")
+	flags.StringVar(&c.output, "o", ".", "Output path.")
+	flags.StringVar(&c.filename, "f", "", "Output file name.")
+	flags.StringVar(&c.colourMode, "c", "none", "Colour mode: "+strings.Join(supportedColourings(), ", "))
+	flags.Float64Var(&c.bailout, "b", 4.0, "Bailout value.")
+	flags.IntVar(&c.width, "w", 1600, "Width of render.")
+	flags.IntVar(&c.height, "h", 1600, "Height of render.")
+	flags.IntVar(&c.maxIterations, "m", 2000, "Maximum Iterations before giving up on finding an escape.")
+	flags.StringVar(&c.gradient, "g", defaultGradient, "Gradient to use: a preset name ("+strings.Join(presetNames(), ", ")+"), a .ggr, .map or .cpt file, or a JSON list of [position, hex] pairs.")
+	flags.StringVar(&c.interiorGradient, "ig", "", "Gradient to use for points that don't escape, coloured by the final modulus of z. Left black if not given.")
+	flags.StringVar(&c.gradientSpace, "gs", rgbInterpolation, "Colour space to interpolate gradients in: "+strings.Join(supportedGradientSpaces, ", "))
+	flags.IntVar(&c.paletteLength, "pl", defaultPaletteLength, "Palette length for smooth and banded colouring.")
+	flags.Float64Var(&c.paletteOffset, "po", 0.0, "Palette offset, in palette entries, for smooth and banded colouring.")
+	flags.IntVar(&c.paletteRepeat, "pr", 1, "Number of times the gradient repeats across the palette.")
+	flags.Float64Var(&c.colourDensity, "pd", 1.0, "Colour density: how quickly escape values move through the palette.")
+	flags.BoolVar(&c.autoFit, "fit", false, "Fit the gradient to the range of escape counts in the render, rather than 0 to the maximum iterations.")
+	flags.Float64Var(&c.fitLow, "fitlo", 0.0, "Percentile of escape counts fitted to the start of the gradient.")
+	flags.Float64Var(&c.fitHigh, "fithi", 100.0, "Percentile of escape counts fitted to the end of the gradient.")
+	flags.IntVar(&c.gradientMin, "gmin", 0, "Escape count at the start of the gradient. Overrides -fit when not 0.")
+	flags.IntVar(&c.gradientMax, "gmax", 0, "Escape count at the end of the gradient. Overrides -fit when not 0.")
+	flags.StringVar(&c.lighting, "light", noLighting, "Lighting: "+strings.Join(supportedLighting, ", "))
+	flags.StringVar(&c.normals, "normals", slopeNormals, "Lighting normals: "+strings.Join(supportedNormals, ", ")+". Analytic normals fall back to slope where the algorithm has no derivative.")
+	flags.Float64Var(&c.lightAngle, "la", 45.0, "Light angle in degrees, anticlockwise from the positive real axis.")
+	flags.Float64Var(&c.lightElevation, "le", 45.0, "Light elevation in degrees.")
+	flags.Float64Var(&c.lightAmbient, "lamb", 0.2, "Ambient light level, from 0 to 1.")
+	flags.Float64Var(&c.lightSpecular, "lspec", 0.5, "Specular strength, from 0 to 1. Phong lighting only.")
+	flags.Float64Var(&c.lightHeight, "lh", 1.0, "Height scale of the surface used for slope normals.")
+	flags.StringVar(&c.mode, "mode", "image", "Mode:  "+strings.Join(supportedModes, ", "))
+	flags.IntVar(&c.pointX, "x", 0, "x cordinate of a pixel, used for translating to the real component. 0,0 is top left.")
+	flags.IntVar(&c.pointY, "y", 0, "y cordinate of a pixel, used for translating to the real component. 0,0 is top left.")
+	flags.Float64Var(&c.constR, "cr", 0.0, "Real component of the const point in a Julia set.")
+	flags.Float64Var(&c.constI, "ci", 0.0, "Imaginary component of the const point in a Julia set.")
+	flags.StringVar(&c.slice, "slice", "", "Plane through the 4D (c, z0) space to draw, as origin;u;v with each vector Re(c),Im(c),Re(z0),Im(z0). 0,0,0,0;1,0,0,0;0,1,0,0 is the Mandelbrot form.")
+	flags.BoolVar(&c.julia, "julia", false, "Draw the Julia form of the formula, with c fixed by -cr and -ci and the plane giving the starting z.")
+	flags.StringVar(&c.layers, "layers", "", "JSON file of layers to composite, each with its own colourMode, gradient, lighting, opacity and blend (normal, multiply, screen, overlay, add).")
+	flags.StringVar(&c.postProcessing, "post", "", "Post-processing filters to apply in order, e.g. glow:0.5,sharpen:1,vignette:0.4,gamma:1.2,levels:10:245,curve:0:0:128:150:255:255")
+	flags.StringVar(&c.simulate, "cvd", "", "Also save a preview simulating a colour vision deficiency: "+strings.Join([]string{protanopia, deuteranopia, tritanopia}, ", "))
+	flags.Float64Var(&c.simulateSeverity, "cvds", 1.0, "Severity of the simulated colour vision deficiency, from 0 for normal vision to 1 for dichromacy.")
+	flags.StringVar(&c.source, "src", "", "Reference image to extract a gradient from, in extractGradient mode.")
+	flags.StringVar(&c.sampling, "sample", kmeansSampling, "How to sample the reference image: "+strings.Join(supportedSamplings, ", "))
+	flags.StringVar(&c.sampleLine, "line", "0,0.5,1,0.5", "Line to sample across the reference image as x0,y0,x1,y1, in fractions of its width and height.")
+	flags.IntVar(&c.stops, "stops", 8, "Number of gradient stops to extract from the reference image, at least 2.")
+	flags.Float64Var(&c.exponent, "n", 2.0, "Exponent of z in Multibrot and Multi-Julia sets, where it may be negative or fractional, and in Phoenix sets, where it is rounded to a whole number of at least 2.")
+	flags.StringVar(&c.polynomial, "poly", "1,0,0,-1", "Comma separated coefficients of the polynomial in Newton and Nova plots, highest power first. May be complex, e.g. 1,0,-2i.")
+	flags.StringVar(&c.roots, "roots", "", "Comma separated roots of the polynomial in Newton and Nova plots, used in place of -poly. Colour Newton plots by root with -c basin.")
+	flags.StringVar(&c.expression, "expr", "z^2 + c", "Formula for -a formula, e.g. z^3 + c*sin(z) or (z+1)(z+c)(z+i). Knows z, c, pixel, i, pi, e, params, + - * / ^ and sin, cos, tan, sinh, cosh, tanh, asin, acos, atan, exp, log, sqrt, conj, abs, arg, re, im, floor.")
+	flags.StringVar(&c.start, "z0", "0", "Starting z of -a formula, an expression that may use c and params.")
+	flags.StringVar(&c.escapeCondition, "escape", "", "Escape condition of -a formula, e.g. abs(z) > 10 || abs(im(z)) > 50. Comparisons use real parts. Defaults to |z| passing the bailout.")
+	flags.StringVar(&c.params, "params", "", "Named params of -a formula, as name=value,... Values may be complex, e.g. k=0.5,w=1+2i.")
+	flags.StringVar(&c.sequence, "seq", "AB", "Sequence of As and Bs in a Lyapunov plot, choosing whether a, the real axis, or b, the imaginary axis, drives each step of the logistic map.")
+	flags.IntVar(&c.warmup, "warmup", 50, "Iterations of the logistic map to discard in a Lyapunov plot before measuring its exponent over -m iterations.")
+	flags.StringVar(&c.chaoticGradient, "cg", "", "Gradient for the chaotic regions of a Lyapunov plot, where -g colours the stable regions. Left black if not given.")
+	flags.IntVar(&c.samples, "samples", 0, "Values of c to sample in a Buddhabrot or Nebulabrot render, or points to plot in an IFS render. Defaults to ten per pixel, or twenty for IFS.")
+	flags.Int64Var(&c.seed, "seed", 1, "Seed of the random numbers of a Buddhabrot, Nebulabrot or IFS render, so renders can be repeated.")
+	flags.StringVar(&c.limits, "limits", "5000,500,50", "Comma separated iteration limits of the red, green and blue channels of a Nebulabrot render.")
+	flags.BoolVar(&c.anti, "anti", false, "Plot the orbits that don't escape within the iteration limit in a Buddhabrot or Nebulabrot render, the anti-Buddhabrot.")
+	flags.StringVar(&c.ifs, "ifs", "fern", fmt.Sprintf("Maps of an IFS render, a preset (%s) or a JSON file of maps, e.g. [{\"a\": 0.5, \"d\": 0.5, \"e\": 0.5, \"weight\": 1}, ...], each moving (x, y) to (a*x + b*y + e, c*x + d*y + f).", strings.Join(ifsPresetNames(), ", ")))
+	flags.StringVar(&c.ifsColouring, "ifsc", densityIFSColouring, fmt.Sprintf("Colouring of an IFS render, one of %s or %s, by the maps that put points in each pixel.", densityIFSColouring, mapIFSColouring))
+	flags.Float64Var(&c.relaxation, "relax", 1.0, "Relaxation factor R of Newton and Nova plots, the fraction of each Newton step to take. Must be positive. Simple roots attract below 2, and a root repeated m times below 2m.")
+}
+
 func max(a float64, b float64) float64 {
 	if a > b {
 		return a
@@ -158,6 +309,10 @@ func min(a float64, b float64) float64 {
 	return a
 }
 
+func clamp(v float64, lower float64, upper float64) float64 {
+	return min(max(v, lower), upper)
+}
+
 func initialiseimage(c config) *image.NRGBA {
 	bounds := image.Rect(0, 0, c.width, c.height)
 	mbi := image.NewNRGBA(bounds)
@@ -165,13 +320,19 @@ func initialiseimage(c config) *image.NRGBA {
 	return mbi
 }
 
-func saveimage(mbi *image.NRGBA, filepath string, filename string) {
+// saveimage writes the image as a JPEG, embedding comment as a JPEG comment segment
+func saveimage(mbi *image.NRGBA, filepath string, filename string, comment string) {
 	file, err := os.Create(filepath + "/" + filename)
 	if err != nil {
 		fmt.Println(err)
 	}
 
-	if err = jpeg.Encode(file, mbi, &jpeg.Options{Quality: jpeg.DefaultQuality}); err != nil {
+	var buf bytes.Buffer
+	if err = jpeg.Encode(&buf, mbi, &jpeg.Options{Quality: jpeg.DefaultQuality}); err != nil {
+		fmt.Println(err)
+	}
+
+	if _, err = file.Write(insertJpegComment(buf.Bytes(), comment)); err != nil {
 		fmt.Println(err)
 	}
 
@@ -180,6 +341,22 @@ func saveimage(mbi *image.NRGBA, filepath string, filename string) {
 	}
 }
 
+// insertJpegComment adds a COM segment straight after the start of image marker
+func insertJpegComment(encoded []byte, comment string) []byte {
+	if comment == "" || len(encoded) < 2 {
+		return encoded
+	}
+
+	if len(comment) > 65533 {
+		comment = comment[:65533]
+	}
+
+	length := len(comment) + 2
+	segment := append([]byte{0xff, 0xfe, byte(length >> 8), byte(length)}, comment...)
+
+	return append(append(append([]byte{}, encoded[:2]...), segment...), encoded[2:]...)
+}
+
 func (p *Plane) getScale(zoom float64, height int, width int) (float64, float64, float64) {
 	var pixelScaleRealAxis = (p.rMax - p.rMin) / float64(width-1) / zoom
 	var pixelScaleImagAxis = (p.iMax - p.iMin) / float64(height-1) / zoom
@@ -193,15 +370,30 @@ func (p *Plane) getScale(zoom float64, height int, width int) (float64, float64,
 }
 
 func (p *Plane) calculateCoordinatesAtPoint(config config) (float64, float64) {
+	return p.coordinatesAt(config, config.pointX, config.pointY)
+}
+
+func (p *Plane) coordinatesAt(config config, x int, y int) (float64, float64) {
 	var pixelScale, pixelOffsetReal, pixelOffsetImag = p.getScale(config.zoom, config.height, config.width)
 
-	var real = config.midX + (float64(config.pointX)-pixelOffsetReal)*pixelScale
-	var imag = config.midY - pixelScale*(-1.0*float64(config.pointY)+pixelOffsetImag)
+	var real = config.midX + (float64(x)-pixelOffsetReal)*pixelScale
+	var imag = config.midY + pixelScale*(-1.0*float64(y)+pixelOffsetImag)
 
 	return real, imag
 }
 
-func (p *Plane) iterateOverPoints(config config, plottedChannel chan PlottedPoint, calc escapeCalculator) {
+// pixelAt is the inverse of coordinatesAt, finding the pixel nearest a point of the plane and
+// whether it is in the image
+func (p *Plane) pixelAt(config config, real float64, imag float64) (int, int, bool) {
+	var pixelScale, pixelOffsetReal, pixelOffsetImag = p.getScale(config.zoom, config.height, config.width)
+
+	var x = int(math.Round((real-config.midX)/pixelScale + pixelOffsetReal))
+	var y = int(math.Round(pixelOffsetImag - (imag-config.midY)/pixelScale))
+
+	return x, y, x >= 0 && x < config.width && y >= 0 && y < config.height
+}
+
+func (p *Plane) iterateOverPoints(config config, plottedChannel chan PlottedPoint, plot pointPlotter) {
 	var pixelScale, pixelOffsetReal, pixelOffsetImag = p.getScale(config.zoom, config.height, config.width)
 
 	pointsChannel := make(chan Point)
@@ -211,8 +403,7 @@ func (p *Plane) iterateOverPoints(config config, plottedChannel chan PlottedPoin
 		wg.Add(1)
 		go func() {
 			for p := range pointsChannel {
-				var escaped, iteration, finalReal, finalImag = calc(p.real, p.imag, config)
-				plottedChannel <- PlottedPoint{p.X, p.Y, finalReal, finalImag, iteration, escaped}
+				plottedChannel <- plot(p, config)
 			}
 			wg.Done()
 		}()
@@ -233,3 +424,12 @@ func (p *Plane) iterateOverPoints(config config, plottedChannel chan PlottedPoin
 }
 
 type escapeCalculator func(real float64, imag float64, config config) (escaped bool, iterations int, finalReal float64, finalImaginary float64)
+
+// A convergenceCalculator applies a convergence test, such as Newton's method, to a point. root is
+// the index of the root the orbit settled on, or -1 if it didn't settle.
+type convergenceCalculator func(real float64, imag float64, config config) (root int, iterations int, finalReal float64, finalImaginary float64)
+
+// A pointPlotter applies a fractal's test to a point of the plane
+type pointPlotter func(p Point, config config) PlottedPoint
+
+type derivativeCalculator func(real float64, imag float64, config config) (derivativeReal float64, derivativeImaginary float64)
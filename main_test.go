@@ -0,0 +1,23 @@
+package main
+
+import "testing"
+
+func TestCoordinatesAtAgreesWithTheRenderedPoints(t *testing.T) {
+	var c config
+	c.width, c.height = 9, 7
+	c.zoom = 2
+	c.midX, c.midY = -0.5, 0.3
+
+	p := Plane{-2.0, 2.0, -1.5, 1.5}
+	plotted := make(chan PlottedPoint, c.width*c.height)
+	p.iterateOverPoints(c, plotted, func(point Point, config config) PlottedPoint {
+		return PlottedPoint{X: point.X, Y: point.Y, real: point.real, imag: point.imag}
+	})
+	close(plotted)
+
+	for point := range plotted {
+		if r, i := p.coordinatesAt(c, point.X, point.Y); r != point.real || i != point.imag {
+			t.Errorf("Pixel %d, %d was at %g, %g, want %g, %g as rendered.", point.X, point.Y, r, i, point.real, point.imag)
+		}
+	}
+}
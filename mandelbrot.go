@@ -1,10 +1,5 @@
 package main
 
-import (
-	"fmt"
-	"strconv"
-)
-
 // A Mandelbrot represents the strongly typed planar space for the mandelbrot fractal
 type mandelbrotPlane struct {
 	Plane
@@ -15,46 +10,23 @@ func newMandelbrot() mandelbrotPlane {
 }
 
 func (m *mandelbrotPlane) process(c config) {
-	if c.midX == -99.0 {
-		c.midX = (m.rMax + m.rMin) / 2.0
-	}
-
-	if c.midY == -99.0 {
-		c.midY = (m.iMax + m.iMin) / 2.0
-	}
-
-	if c.mode == imageMode {
-		m.image(c)
-	} else if c.mode == coordinatesMode {
-		var r, i = m.calculateCoordinatesAtPoint(c)
-		fmt.Printf("%18.17e, %18.17e\n", r, i)
-	}
+	m.processFormula(c, m.formula(c))
 }
 
-func (m *mandelbrotPlane) image(c config) {
-	initialiseGradient(c.gradient)
-
-	mbi := initialiseimage(c)
-
-	plottedChannel := make(chan PlottedPoint)
-
-	go func(points <-chan PlottedPoint) {
-		for p := range points {
-			if p.Escaped {
-				mbi.Set(p.X, p.Y, getPixelColour(p, c.maxIterations, c.colourMode))
-			}
-		}
-	}(plottedChannel)
-
-	m.iterateOverPoints(c, plottedChannel, m.calculateEscape)
-
-	if c.filename == "" {
-		c.filename = "mandelbrot_" + strconv.FormatFloat(c.midX, 'E', -1, 64) + "_" + strconv.FormatFloat(c.midY, 'E', -1, 64) + "_" + strconv.FormatFloat(c.zoom, 'E', -1, 64) + ".jpg"
+func (m *mandelbrotPlane) formula(c config) formula {
+	return formula{
+		name:            "mandelbrot_",
+		juliaName:       "julia_",
+		orbit:           quadraticOrbit,
+		start:           startAtZero,
+		bailout:         c.bailout * c.bailout,
+		juliaBailout:    determineJuliaBailout,
+		degree:          2.0,
+		juliaPlane:      newJulia().Plane,
+		escape:          m.calculateEscape,
+		derivative:      m.calculateDerivative,
+		juliaDerivative: calculateJuliaDerivative,
 	}
-
-	saveimage(mbi, c.output, c.filename)
-
-	fmt.Printf("%s/%s\n", c.output, c.filename)
 }
 
 func (m *mandelbrotPlane) calculateEscape(real float64, imag float64, config config) (bool, int, float64, float64) {
@@ -98,3 +70,17 @@ func (m *mandelbrotPlane) calculateEscape(real float64, imag float64, config con
 
 	return iteration < config.maxIterations, iteration, x, y
 }
+
+// calculateDerivative follows the same orbit as calculateEscape, tracking dz/dc alongside z
+func (m *mandelbrotPlane) calculateDerivative(real float64, imag float64, config config) (float64, float64) {
+	var zr, zi, dr, di float64
+	var bailout = config.bailout * config.bailout
+
+	for iteration := 1; zr*zr+zi*zi <= bailout && iteration < config.maxIterations; iteration++ {
+		// dz = 2 * z * dz + 1
+		dr, di = 2*(zr*dr-zi*di)+1, 2*(zr*di+zi*dr)
+		zr, zi = zr*zr-zi*zi+real, 2*zr*zi+imag
+	}
+
+	return dr, di
+}
@@ -0,0 +1,127 @@
+package main
+
+import (
+	"math"
+	"math/cmplx"
+)
+
+// A multibrotPlane represents the strongly typed planar space for the Multibrot fractal, z**n + c
+type multibrotPlane struct {
+	Plane
+}
+
+// A multiJuliaPlane represents the strongly typed planar space for the Julia sets of z**n + c
+type multiJuliaPlane struct {
+	Plane
+}
+
+func newMultibrot(n float64) multibrotPlane {
+	switch {
+	case n == 2:
+		return multibrotPlane{Plane{-2.25, 0.75, -1.5, 1.5}}
+	case n > 2:
+		return multibrotPlane{Plane{-1.5, 1.5, -1.5, 1.5}}
+	case n > 1:
+		return multibrotPlane{Plane{-2.5, 1.5, -2.0, 2.0}}
+	}
+	// Negative and small exponents spread much further from the origin
+	return multibrotPlane{Plane{-3.0, 3.0, -3.0, 3.0}}
+}
+
+func newMultiJulia(n float64) multiJuliaPlane {
+	if n > 1 && n < 2 {
+		return multiJuliaPlane{Plane{-3.0, 3.0, -3.0, 3.0}}
+	}
+	return multiJuliaPlane{Plane{-2.0, 2.0, -2.0, 2.0}}
+}
+
+func (m *multibrotPlane) process(c config) {
+	m.processFormula(c, m.formula(c))
+}
+
+func (m *multibrotPlane) formula(c config) formula {
+	var n = c.exponent
+	var start = startAtZero
+	if n <= 0 {
+		// 0**n isn't defined for n <= 0, so those orbits start a step along, at c
+		start = startAtC
+	}
+
+	// Orbits that stay bounded never leave |z| <= 2**(1/(n-1)), which is what |c| = 1 gives
+	var radius = multibrotRadius(n, 1.0, c)
+
+	var degree float64
+	if n > 1 {
+		degree = n
+	}
+
+	return formula{
+		name:      "multibrot_",
+		juliaName: "multijulia_",
+		orbit: func(zReal float64, zImag float64, cReal float64, cImag float64, bailout float64, config config) (bool, int, float64, float64) {
+			return multibrotOrbit(complex(zReal, zImag), complex(cReal, cImag), math.Sqrt(bailout), config)
+		},
+		start:   start,
+		bailout: radius * radius,
+		juliaBailout: func(cReal float64, cImag float64) float64 {
+			var radius = multibrotRadius(n, math.Hypot(cReal, cImag), c)
+			return radius * radius
+		},
+		degree:     degree,
+		juliaPlane: newMultiJulia(n).Plane,
+	}
+}
+
+// process draws the Julia form of the Multibrot formula
+func (m *multiJuliaPlane) process(c config) {
+	var multibrot = newMultibrot(c.exponent)
+	var f = multibrot.formula(c)
+	f.juliaPlane = m.Plane
+
+	c.julia = true
+	m.processFormula(c, f)
+}
+
+// multibrotOrbit iterates z = z**n + c from z until it passes the escape radius
+func multibrotOrbit(z complex128, c complex128, radius float64, config config) (bool, int, float64, float64) {
+	var n = config.exponent
+	var bailout = radius * radius
+	var iteration int
+
+	var power = func(z complex128) complex128 { return cmplx.Pow(z, complex(n, 0)) }
+	if n == math.Trunc(n) && math.Abs(n) <= math.MaxInt32 {
+		power = func(z complex128) complex128 { return powInt(z, int(n)) }
+	}
+
+	for iteration = 0; real(z)*real(z)+imag(z)*imag(z) <= bailout && iteration < config.maxIterations; iteration++ {
+		z = power(z) + c
+	}
+
+	return iteration < config.maxIterations, iteration, real(z), imag(z)
+}
+
+// multibrotRadius is an escape radius for z**n + c. For n > 1 any orbit passing
+// max(2, 1 + |c|)**(1/(n-1)) grows without bound. Other exponents have no such radius, so
+// fall back to the configured bailout.
+func multibrotRadius(n float64, cAbs float64, config config) float64 {
+	if n > 1 {
+		return math.Pow(max(2, 1+cAbs), 1/(n-1))
+	}
+	return config.bailout
+}
+
+// powInt raises z to a whole power by repeated squaring
+func powInt(z complex128, n int) complex128 {
+	if n < 0 {
+		return 1 / powInt(z, -n)
+	}
+
+	var result = complex(1, 0)
+	for ; n > 0; n >>= 1 {
+		if n&1 == 1 {
+			result *= z
+		}
+		z *= z
+	}
+	return result
+}
@@ -0,0 +1,34 @@
+package main
+
+import (
+	"math/cmplx"
+	"testing"
+)
+
+func TestPowIntMatchesPow(t *testing.T) {
+	z := complex(0.3, -1.2)
+
+	for _, n := range []int{-3, -1, 0, 1, 2, 5} {
+		got := powInt(z, n)
+		want := cmplx.Pow(z, complex(float64(n), 0))
+		if cmplx.Abs(got-want) > 1e-12 {
+			t.Errorf("z**%d was incorrect, got: %v, want: %v.", n, got, want)
+		}
+	}
+}
+
+func TestMultibrotOfTwoMatchesMandelbrot(t *testing.T) {
+	var c config
+	c.maxIterations = 1000
+	c.exponent = 2.0
+
+	escaped, iterations, finalR, finalI := multibrotOrbit(complex(0, 0), complex(-2.25, 0.0), 4.0, c)
+
+	if !escaped || iterations != 3 || finalR != 5.66015625 || finalI != 0.0 {
+		t.Errorf("Escape was incorrect, got: %t, %d, %f, %f.", escaped, iterations, finalR, finalI)
+	}
+
+	if escaped, _, _, _ := multibrotOrbit(complex(0, 0), complex(-1.0, 0.0), 2.0, c); escaped {
+		t.Errorf("-1 should not escape z**2 + c")
+	}
+}
@@ -1,8 +1,7 @@
 package main
 
 import (
-	"fmt"
-	"strconv"
+	"math"
 )
 
 // A MutantMandelbrot represents the strongly typed planar space for the Mutated Mandelbrot fractal
@@ -15,68 +14,41 @@ func newMutantMandelbrot() mutantMandelbrotPlane {
 }
 
 func (m mutantMandelbrotPlane) process(c config) {
-	if c.midX == -99.0 {
-		c.midX = (m.rMax + m.rMin) / 2.0
-	}
-
-	if c.midY == -99.0 {
-		c.midY = (m.iMax + m.iMin) / 2.0
-	}
+	m.processFormula(c, m.formula())
+}
 
-	if c.mode == imageMode {
-		m.image(c)
-	} else if c.mode == coordinatesMode {
-		var r, i = m.calculateCoordinatesAtPoint(c)
-		fmt.Printf("%18.17e, %18.17e\n", r, i)
+func (m *mutantMandelbrotPlane) formula() formula {
+	var quadratic = polynomialJuliaBailout(2.0)
+
+	return formula{
+		name:    "mutant_mb_",
+		orbit:   mutantMandelbrotOrbit,
+		start:   startAtC,
+		bailout: 20.0,
+		// c drifts along with the orbit, so keep to the Mandelbrot form's radius where it is larger
+		juliaBailout: func(cReal float64, cImag float64) float64 { return math.Max(20.0, quadratic(cReal, cImag)) },
+		degree:       2.0,
+		juliaPlane:   Plane{-2.0, 2.0, -2.0, 2.0},
 	}
 }
 
-func (m *mutantMandelbrotPlane) image(c config) {
-	initialiseGradient(c.gradient)
-
-	mbi := initialiseimage(c)
-
-	plottedChannel := make(chan PlottedPoint)
-
-	go func(points <-chan PlottedPoint) {
-		for p := range points {
-			if p.Escaped {
-				mbi.Set(p.X, p.Y, getPixelColour(p, c.maxIterations, c.colourMode))
-			}
+// mutantMandelbrotOrbit iterates z**2 + c, nudging c along the orbit every so often
+func mutantMandelbrotOrbit(zx float64, zy float64, x float64, y float64, bailout float64, config config) (bool, int, float64, float64) {
+	var n = 50
+	var p = 100
+	var count int
+
+	for count = 0; count < config.maxIterations && zx*zx+zy*zy < bailout; count++ {
+		if 0 == (count+1)%n {
+			x += zx * float64(count) / float64(p)
+			y += zy * float64(count) / float64(p)
+			n--
+			p++
 		}
-	}(plottedChannel)
-
-	var checkIfPointEscapes escapeCalculator = func(real float64, imag float64, config config) (bool, int, float64, float64) {
-		var zx = real
-		var zy = imag
-		var x = real
-		var y = imag
-		var n = 50
-		var p = 100
-		var count int
-
-		for count = 0; count < config.maxIterations && zx*zx+zy*zy < 20.0; count++ {
-			if 0 == (count+1)%n {
-				x += zx * float64(count) / float64(p)
-				y += zy * float64(count) / float64(p)
-				n--
-				p++
-			}
-			var newZx = zx*zx - zy*zy + x
-			zy = 2*zx*zy + y
-			zx = newZx
-		}
-
-		return count < config.maxIterations, count, zx, zy
+		var newZx = zx*zx - zy*zy + x
+		zy = 2*zx*zy + y
+		zx = newZx
 	}
 
-	m.iterateOverPoints(c, plottedChannel, checkIfPointEscapes)
-
-	if c.filename == "" {
-		c.filename = "mutant_mb_" + strconv.FormatFloat(c.midX, 'E', -1, 64) + "_" + strconv.FormatFloat(c.midY, 'E', -1, 64) + "_" + strconv.FormatFloat(c.zoom, 'E', -1, 64) + ".jpg"
-	}
-
-	saveimage(mbi, c.output, c.filename)
-
-	fmt.Printf("%s/%s\n", c.output, c.filename)
+	return count < config.maxIterations, count, zx, zy
 }
@@ -0,0 +1,225 @@
+package main
+
+import (
+	"fmt"
+	"math/cmplx"
+	"strconv"
+	"strings"
+)
+
+const (
+	newtonStepTolerance = 1e-8 // Orbits have settled once Newton's steps are this small
+	newtonRootTolerance = 1e-3 // and are this close to a root
+)
+
+// A newtonPlane represents the strongly typed planar space for the Newton fractal of a polynomial
+type newtonPlane struct {
+	Plane
+}
+
+func newNewton() newtonPlane {
+	return newtonPlane{Plane{-2.0, 2.0, -2.0, 2.0}}
+}
+
+func (m *newtonPlane) process(c config) {
+	if c.midX == -99.0 {
+		c.midX = (m.rMax + m.rMin) / 2.0
+	}
+
+	if c.midY == -99.0 {
+		c.midY = (m.iMax + m.iMin) / 2.0
+	}
+
+	if c.mode == imageMode {
+		m.image(c)
+	} else if c.mode == coordinatesMode {
+		var r, i = m.calculateCoordinatesAtPoint(c)
+		fmt.Printf("%18.17e, %18.17e\n", r, i)
+	}
+}
+
+func (m *newtonPlane) image(c config) {
+	f, err := m.fractal(c)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+
+	m.render(c, f)
+}
+
+// fractal builds the convergence test for the configured polynomial. The basin colouring
+// spaces every root of the polynomial along the gradient, not just those in view.
+func (m *newtonPlane) fractal(c config) (escapeTimeFractal, error) {
+	if err := checkRelaxation(c.relaxation); err != nil {
+		return escapeTimeFractal{}, fmt.Errorf("newton: %s", err)
+	}
+
+	p, roots, err := newtonPolynomial(c)
+	if err != nil {
+		return escapeTimeFractal{}, err
+	}
+
+	return escapeTimeFractal{name: "newton_", converge: newtonConvergence(p, roots, c.relaxation), roots: len(roots)}, nil
+}
+
+// newtonPolynomial reads the polynomial from its roots if given, otherwise from its coefficients,
+// finding the roots it doesn't have.
+func newtonPolynomial(c config) (polynomial, []complex128, error) {
+	if c.roots != "" {
+		roots, err := parseComplexList(c.roots)
+		if err != nil {
+			return nil, nil, fmt.Errorf("newton: %s", err)
+		}
+		if len(roots) == 0 {
+			return nil, nil, fmt.Errorf("newton: -roots needs at least one root")
+		}
+		return polynomialFromRoots(roots), roots, nil
+	}
+
+	coefficients, err := parseComplexList(c.polynomial)
+	if err != nil {
+		return nil, nil, fmt.Errorf("newton: %s", err)
+	}
+
+	p := polynomial(coefficients).trim()
+	if len(p) < 2 {
+		return nil, nil, fmt.Errorf("newton: the polynomial %q has no roots", c.polynomial)
+	}
+	return p, p.roots(), nil
+}
+
+// checkRelaxation rejects relaxations that never step towards a root. A simple root attracts
+// orbits for relaxations below 2 and a root repeated m times for those below 2m, so larger
+// relaxations are allowed for polynomials with repeated roots.
+func checkRelaxation(relaxation float64) error {
+	if relaxation <= 0 {
+		return fmt.Errorf("-relax must be positive, got %g", relaxation)
+	}
+	return nil
+}
+
+// newtonConvergence iterates z = z - relaxation * p(z) / p'(z) from the point, reporting the
+// root the orbit settles on.
+func newtonConvergence(p polynomial, roots []complex128, relaxation float64) convergenceCalculator {
+	var a = complex(relaxation, 0)
+
+	return func(r float64, i float64, config config) (int, int, float64, float64) {
+		var z = complex(r, i)
+		var iteration int
+
+		for iteration = 0; iteration < config.maxIterations; iteration++ {
+			value, slope := p.evaluate(z)
+			if slope == 0 {
+				break
+			}
+
+			step := a * value / slope
+			z -= step
+			if cmplx.Abs(step) < newtonStepTolerance {
+				return nearestRoot(roots, z), iteration, real(z), imag(z)
+			}
+		}
+
+		return -1, iteration, real(z), imag(z)
+	}
+}
+
+// nearestRoot returns the index of the root within newtonRootTolerance of z, or -1
+func nearestRoot(roots []complex128, z complex128) int {
+	for i, root := range roots {
+		if cmplx.Abs(z-root) < newtonRootTolerance {
+			return i
+		}
+	}
+	return -1
+}
+
+// A polynomial holds its complex coefficients, highest power first
+type polynomial []complex128
+
+// evaluate returns the value and the derivative of the polynomial at z, by Horner's method
+func (p polynomial) evaluate(z complex128) (complex128, complex128) {
+	var value, slope complex128
+	for _, coefficient := range p {
+		slope = slope*z + value
+		value = value*z + coefficient
+	}
+	return value, slope
+}
+
+// trim drops leading zero coefficients
+func (p polynomial) trim() polynomial {
+	for len(p) > 0 && p[0] == 0 {
+		p = p[1:]
+	}
+	return p
+}
+
+func polynomialFromRoots(roots []complex128) polynomial {
+	var p = polynomial{1}
+	for _, root := range roots {
+		// Multiply by (z - root)
+		next := make(polynomial, len(p)+1)
+		for i, coefficient := range p {
+			next[i] += coefficient
+			next[i+1] -= coefficient * root
+		}
+		p = next
+	}
+	return p
+}
+
+// roots finds every root of the polynomial together by the Durand-Kerner method
+func (p polynomial) roots() []complex128 {
+	var degree = len(p) - 1
+	var roots = make([]complex128, degree)
+	for i := range roots {
+		roots[i] = cmplx.Pow(complex(0.4, 0.9), complex(float64(i), 0))
+	}
+
+	var monic = func(z complex128) complex128 {
+		value, _ := p.evaluate(z)
+		return value / p[0]
+	}
+
+	for pass := 0; pass < 500; pass++ {
+		var moved float64
+		for i := range roots {
+			var denominator = complex(1, 0)
+			for j := range roots {
+				if i != j {
+					denominator *= roots[i] - roots[j]
+				}
+			}
+
+			step := monic(roots[i]) / denominator
+			roots[i] -= step
+			moved += cmplx.Abs(step)
+		}
+
+		if moved < 1e-14 {
+			break
+		}
+	}
+
+	return roots
+}
+
+// parseComplexList reads comma separated complex numbers such as 1,-0.5+0.866i,2i
+func parseComplexList(str string) ([]complex128, error) {
+	var values []complex128
+	for _, field := range strings.Split(str, ",") {
+		field = strings.TrimSpace(field)
+		if field == "" {
+			continue
+		}
+
+		value, err := strconv.ParseComplex(field, 128)
+		if err != nil {
+			return nil, fmt.Errorf("%q is not a complex number", field)
+		}
+		values = append(values, value)
+	}
+	return values, nil
+}
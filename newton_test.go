@@ -0,0 +1,123 @@
+package main
+
+import (
+	"image/color"
+	"math/cmplx"
+	"testing"
+)
+
+func TestPolynomialRootsOfUnity(t *testing.T) {
+	p := polynomial{1, 0, 0, -1}
+	roots := p.roots()
+
+	if len(roots) != 3 {
+		t.Fatalf("Found %d roots of z**3 - 1, want 3.", len(roots))
+	}
+
+	for _, root := range roots {
+		if value, _ := p.evaluate(root); cmplx.Abs(value) > 1e-9 {
+			t.Errorf("%v is not a root of z**3 - 1, p = %v.", root, value)
+		}
+	}
+}
+
+func TestPolynomialFromRootsExpandsTheProduct(t *testing.T) {
+	p := polynomialFromRoots([]complex128{1, -1})
+	want := polynomial{1, 0, -1}
+
+	for i := range want {
+		if p[i] != want[i] {
+			t.Errorf("Coefficients were %v, want %v.", p, want)
+			break
+		}
+	}
+}
+
+func TestNewtonConvergesToTheNearestRoot(t *testing.T) {
+	var c config
+	c.maxIterations = 100
+	roots := []complex128{1, -1, 1i}
+	converge := newtonConvergence(polynomialFromRoots(roots), roots, 1.0)
+
+	if root, _, _, _ := converge(0.9, 0.1, c); root != 0 {
+		t.Errorf("Converged to root %d from 0.9+0.1i, want 0.", root)
+	}
+
+	if root, _, _, _ := converge(-1.2, 0.0, c); root != 1 {
+		t.Errorf("Converged to root %d from -1.2, want 1.", root)
+	}
+
+	if root, _, _, _ := converge(0.05, 1.1, c); root != 2 {
+		t.Errorf("Converged to root %d from 0.05+1.1i, want 2.", root)
+	}
+}
+
+func TestRelaxationMustBePositive(t *testing.T) {
+	var c config
+	c.roots = "1,-1,1i"
+
+	m := newNewton()
+	for _, relaxation := range []float64{0, -0.5} {
+		c.relaxation = relaxation
+		if _, err := m.fractal(c); err == nil {
+			t.Errorf("A relaxation of %g was accepted, want an error.", relaxation)
+		}
+	}
+
+	// Past 2 simple roots repel, but a double root still attracts up to 4
+	c.maxIterations = 200
+	roots := []complex128{1, 1}
+	if root, _, _, _ := newtonConvergence(polynomialFromRoots(roots), roots, 3.0)(1.3, 0.2, c); root != 0 {
+		t.Errorf("With a relaxation of 3 the double root didn't attract, settled on %d.", root)
+	}
+}
+
+func TestParseComplexListRejectsNonsense(t *testing.T) {
+	if _, err := parseComplexList("1,banana"); err == nil {
+		t.Errorf("Parsed 1,banana, want an error.")
+	}
+}
+
+func TestBasinColoursDontDependOnTheView(t *testing.T) {
+	var c config
+	c.roots = "1,-1,1i"
+	c.relaxation = 1.0
+	c.maxIterations = 50
+	c.colourMode = basinColouring
+	c.colourDensity = 1.0
+	c.width, c.height = 9, 9
+	c.zoom = 1
+	c.midX, c.midY = 0, 0
+
+	m := newNewton()
+	f, err := m.fractal(c)
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	// The colour of the basin of 1, seen at the centre of a view of every basin and of a view
+	// close around 1, where no other basin shows
+	var colourAt1 = func(c config) color.NRGBA {
+		field := m.plotField(c, f.plotter())
+		ctx := newColouringContext(c, initialiseGradient("greyscale", rgbInterpolation), field, f.smoothing)
+		ctx.roots = f.roots
+
+		point := field.at(c.width/2, c.height/2)
+		if !point.Escaped || point.Root != 0 {
+			t.Fatalf("The centre of the view at %f settled on root %d, want the root 1.", c.midX, point.Root)
+		}
+		// Compare the hue of the basin, not how quickly the point settled
+		point.Iterations = 0
+		return getPixelColour(point, ctx)
+	}
+
+	c.midX = 0.9
+	wide := colourAt1(c)
+
+	c.midX, c.zoom = 1.0, 100
+	near := colourAt1(c)
+
+	if wide != near {
+		t.Errorf("The basin of 1 was %v in the wide view and %v close up.", wide, near)
+	}
+}
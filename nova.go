@@ -0,0 +1,81 @@
+package main
+
+import (
+	"fmt"
+	"math/cmplx"
+)
+
+// A novaPlane represents the strongly typed planar space for the Nova fractal,
+// z = z - R * p(z) / p'(z) + c
+type novaPlane struct {
+	Plane
+}
+
+func newNova() novaPlane {
+	return novaPlane{Plane{-2.0, 2.0, -1.5, 1.5}}
+}
+
+func (m *novaPlane) process(c config) {
+	if err := checkRelaxation(c.relaxation); err != nil {
+		fmt.Printf("nova: %s\n", err)
+		return
+	}
+
+	p, roots, err := newtonPolynomial(c)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+
+	m.processFormula(c, m.formula(p, roots, c.relaxation))
+}
+
+// Nova orbits settle rather than escape, so the escape radii go unused, points that settle
+// count as escaped and escape counts aren't smoothed.
+func (m *novaPlane) formula(p polynomial, roots []complex128, relaxation float64) formula {
+	// Roots of p are critical points of Newton's method. Start from the one nearest 1, which is
+	// the customary start for z**3 - 1.
+	var start = roots[0]
+	for _, root := range roots {
+		if cmplx.Abs(root-1) < cmplx.Abs(start-1) {
+			start = root
+		}
+	}
+
+	return formula{
+		name:         "nova_",
+		orbit:        novaOrbit(p, relaxation),
+		start:        func(r float64, i float64) (float64, float64) { return real(start), imag(start) },
+		juliaBailout: func(cReal float64, cImag float64) float64 { return 0.0 },
+		juliaPlane:   Plane{-2.0, 2.0, -2.0, 2.0},
+		settles:      true,
+	}
+}
+
+// novaOrbit iterates z = z - relaxation * p(z) / p'(z) + c until the steps become smaller than
+// newtonStepTolerance
+func novaOrbit(p polynomial, relaxation float64) orbitCalculator {
+	var a = complex(relaxation, 0)
+
+	return func(zr float64, zi float64, cr float64, ci float64, bailout float64, config config) (bool, int, float64, float64) {
+		var z = complex(zr, zi)
+		var c = complex(cr, ci)
+		var iteration int
+
+		for iteration = 0; iteration < config.maxIterations; iteration++ {
+			value, slope := p.evaluate(z)
+			if slope == 0 {
+				break
+			}
+
+			next := z - a*value/slope + c
+			settled := cmplx.Abs(next-z) < newtonStepTolerance
+			z = next
+			if settled {
+				return true, iteration, real(z), imag(z)
+			}
+		}
+
+		return false, iteration, real(z), imag(z)
+	}
+}
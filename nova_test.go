@@ -0,0 +1,37 @@
+package main
+
+import (
+	"math"
+	"testing"
+)
+
+func TestNovaWithoutCSettlesOnARoot(t *testing.T) {
+	var c config
+	c.maxIterations = 100
+	orbit := novaOrbit(polynomial{1, 0, 0, -1}, 1.0)
+
+	settled, _, finalR, finalI := orbit(1.2, 0.1, 0.0, 0.0, 0.0, c)
+	if !settled {
+		t.Fatalf("Nova orbit from 1.2+0.1i didn't settle.")
+	}
+
+	if math.Abs(finalR-1.0) > 1e-6 || math.Abs(finalI) > 1e-6 {
+		t.Errorf("Nova orbit settled at %f%+fi, want 1.", finalR, finalI)
+	}
+}
+
+func TestNovaIsNotSmoothed(t *testing.T) {
+	var c config
+	c.width, c.height = 20, 15
+	c.zoom = 1
+	c.maxIterations = 50
+
+	m := newNova()
+	f := m.formula(polynomial{1, 0, 0, -1}, []complex128{1}, 1.0).mandelbrotForm()
+	field := m.plotField(c, f.plotter())
+
+	// Settled orbits end wherever they settle, which says nothing about an escape radius
+	if s := f.estimateSmoothing(field); s != (smoothing{}) {
+		t.Errorf("Nova was smoothed by %+v, want no smoothing.", s)
+	}
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"math"
+)
+
+// A phoenixPlane represents the strongly typed planar space for the Phoenix fractal,
+// z = z**n + Re(c) + Im(c) * the previous z
+type phoenixPlane struct {
+	Plane
+}
+
+func newPhoenix() phoenixPlane {
+	return phoenixPlane{Plane{-2.0, 1.0, -1.5, 1.5}}
+}
+
+func (m *phoenixPlane) process(c config) {
+	m.processFormula(c, m.formula(c))
+}
+
+func (m *phoenixPlane) formula(c config) formula {
+	var n = math.Max(2, math.Round(c.exponent))
+
+	// Past 2 + |Re(c)| + |Im(c)| the previous z can't hold an orbit back
+	var juliaBailout = func(cReal float64, cImag float64) float64 {
+		radius := 2 + math.Abs(cReal) + math.Abs(cImag)
+		return radius * radius
+	}
+
+	return formula{
+		name:         "phoenix_",
+		orbit:        phoenixOrbit(int(n)),
+		start:        startAtZero,
+		bailout:      juliaBailout(2.0, 2.0),
+		juliaBailout: juliaBailout,
+		degree:       n,
+		juliaPlane:   Plane{-2.0, 2.0, -1.5, 1.5},
+	}
+}
+
+// phoenixOrbit iterates z = z**n + Re(c) + Im(c) * the previous z, which starts at 0
+func phoenixOrbit(n int) orbitCalculator {
+	return func(zr float64, zi float64, cr float64, ci float64, bailout float64, config config) (bool, int, float64, float64) {
+		var z = complex(zr, zi)
+		var previous complex128
+		var p, q = complex(cr, 0), complex(ci, 0)
+		var iteration int
+
+		for iteration = 0; real(z)*real(z)+imag(z)*imag(z) < bailout && iteration < config.maxIterations; iteration++ {
+			z, previous = powInt(z, n)+p+q*previous, z
+		}
+
+		return iteration < config.maxIterations, iteration, real(z), imag(z)
+	}
+}
@@ -0,0 +1,28 @@
+package main
+
+import "testing"
+
+func TestPhoenixWithoutHistoryIsTheQuadraticOrbit(t *testing.T) {
+	var c config
+	c.maxIterations = 200
+	orbit := phoenixOrbit(2)
+
+	for _, p := range [][2]float64{{0.1, 0.2}, {-0.7, 0.3}, {1.2, -0.9}} {
+		escapedP, iterationsP, _, _ := orbit(p[0], p[1], -0.75, 0.0, 4.0, c)
+		escapedQ, iterationsQ, _, _ := quadraticOrbit(p[0], p[1], -0.75, 0.0, 4.0, c)
+		if escapedP != escapedQ || iterationsP != iterationsQ {
+			t.Errorf("From %v Phoenix gave %t after %d, z**2 + c %t after %d.", p, escapedP, iterationsP, escapedQ, iterationsQ)
+		}
+	}
+}
+
+func TestPhoenixUsesThePreviousZ(t *testing.T) {
+	var c config
+	c.maxIterations = 3
+
+	// From 1: z1 = 1 + 0 + 0.5*0 = 1, z2 = 1 + 0.5*1 = 1.5, z3 = 2.25 + 0.5*1 = 2.75
+	_, _, finalR, finalI := phoenixOrbit(2)(1.0, 0.0, 0.0, 0.5, 100.0, c)
+	if finalR != 2.75 || finalI != 0.0 {
+		t.Errorf("Phoenix orbit ended at %f%+fi, want 2.75.", finalR, finalI)
+	}
+}
@@ -0,0 +1,225 @@
+package main
+
+import (
+	"fmt"
+	"image"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/gilmae/interpolation"
+)
+
+// Post-processing filters, given to -post as a comma separated chain of name:arg:arg...
+const (
+	glowFilter     = "glow"     // glow:strength[:threshold[:radius]] adds a blurred copy of the bright filaments
+	sharpenFilter  = "sharpen"  // sharpen:amount[:radius] is an unsharp mask
+	vignetteFilter = "vignette" // vignette:strength[:radius] darkens towards the corners
+	gammaFilter    = "gamma"    // gamma:g brightens midtones when g > 1
+	levelsFilter   = "levels"   // levels:black:white[:gamma] stretches the black and white points
+	curveFilter    = "curve"    // curve:x0:y0:x1:y1:... maps channel values through a smooth curve
+)
+
+// A filter adjusts a finished image in place
+type filter func(img *image.NRGBA)
+
+// parsePostProcessing turns a chain such as "glow:0.6,sharpen:1,gamma:1.2" into filters,
+// applied in the order given.
+func parsePostProcessing(chain string) ([]filter, error) {
+	var filters []filter
+
+	for _, spec := range strings.Split(chain, ",") {
+		spec = strings.TrimSpace(spec)
+		if spec == "" {
+			continue
+		}
+
+		fields := strings.Split(spec, ":")
+		name := fields[0]
+
+		var args = make([]float64, len(fields)-1)
+		for i, f := range fields[1:] {
+			var err error
+			if args[i], err = strconv.ParseFloat(f, 64); err != nil {
+				return nil, fmt.Errorf("post processing: %s has an invalid value %q", name, f)
+			}
+		}
+
+		var arg = func(i int, fallback float64) float64 {
+			if i < len(args) {
+				return args[i]
+			}
+			return fallback
+		}
+
+		switch name {
+		case glowFilter:
+			filters = append(filters, glow(arg(0, 0.5), arg(1, 0.6), arg(2, 8)))
+		case sharpenFilter:
+			filters = append(filters, unsharpMask(arg(0, 0.8), arg(1, 2)))
+		case vignetteFilter:
+			filters = append(filters, vignette(arg(0, 0.5), arg(1, 0.5)))
+		case gammaFilter:
+			g := arg(0, 1.0)
+			if g <= 0 {
+				return nil, fmt.Errorf("post processing: gamma must be positive")
+			}
+			filters = append(filters, mapChannels(func(v float64) float64 { return 255 * math.Pow(v/255, 1/g) }))
+		case levelsFilter:
+			black, white, g := arg(0, 0), arg(1, 255), arg(2, 1.0)
+			if white <= black || g <= 0 {
+				return nil, fmt.Errorf("post processing: levels needs black < white and a positive gamma")
+			}
+			filters = append(filters, mapChannels(func(v float64) float64 {
+				return 255 * math.Pow(clamp((v-black)/(white-black), 0, 1), 1/g)
+			}))
+		case curveFilter:
+			if len(args) < 4 || len(args)%2 != 0 {
+				return nil, fmt.Errorf("post processing: curve needs at least two x:y points")
+			}
+			var xs, ys []float64
+			for i := 0; i < len(args); i += 2 {
+				if i > 0 && args[i] <= args[i-2] {
+					return nil, fmt.Errorf("post processing: curve points must have increasing x")
+				}
+				xs = append(xs, args[i])
+				ys = append(ys, args[i+1])
+			}
+			curve := interpolation.CreateMonotonicCubic(xs, ys)
+			filters = append(filters, mapChannels(func(v float64) float64 { return curve(clamp(v, xs[0], xs[len(xs)-1])) }))
+		default:
+			return nil, fmt.Errorf("post processing: unknown filter %q", name)
+		}
+	}
+
+	return filters, nil
+}
+
+func postProcess(img *image.NRGBA, filters []filter) {
+	for _, f := range filters {
+		f(img)
+	}
+}
+
+// mapChannels applies the same mapping to the red, green and blue channels of every pixel
+func mapChannels(mapping func(v float64) float64) filter {
+	var table [256]uint8
+	for i := range table {
+		table[i] = uint8(math.Round(clamp(mapping(float64(i)), 0, 255)))
+	}
+
+	return func(img *image.NRGBA) {
+		for i := 0; i < len(img.Pix); i += 4 {
+			img.Pix[i] = table[img.Pix[i]]
+			img.Pix[i+1] = table[img.Pix[i+1]]
+			img.Pix[i+2] = table[img.Pix[i+2]]
+		}
+	}
+}
+
+// glow adds a blurred copy of the pixels brighter than threshold, so bright filaments bloom
+func glow(strength float64, threshold float64, radius float64) filter {
+	return func(img *image.NRGBA) {
+		bright := make([]float64, len(img.Pix))
+		for i := 0; i < len(img.Pix); i += 4 {
+			r, g, b := float64(img.Pix[i]), float64(img.Pix[i+1]), float64(img.Pix[i+2])
+			if (0.2126*r+0.7152*g+0.0722*b)/255 > threshold {
+				bright[i], bright[i+1], bright[i+2] = r, g, b
+			}
+		}
+
+		blurred := blur(bright, img.Rect.Dx(), img.Rect.Dy(), int(radius))
+		for i := 0; i < len(img.Pix); i += 4 {
+			for k := 0; k < 3; k++ {
+				img.Pix[i+k] = uint8(clamp(float64(img.Pix[i+k])+strength*blurred[i+k], 0, 255))
+			}
+		}
+	}
+}
+
+// unsharpMask sharpens by adding back the difference between the image and a blurred copy
+func unsharpMask(amount float64, radius float64) filter {
+	return func(img *image.NRGBA) {
+		original := make([]float64, len(img.Pix))
+		for i, v := range img.Pix {
+			original[i] = float64(v)
+		}
+
+		blurred := blur(original, img.Rect.Dx(), img.Rect.Dy(), int(radius))
+		for i := 0; i < len(img.Pix); i += 4 {
+			for k := 0; k < 3; k++ {
+				img.Pix[i+k] = uint8(clamp(original[i+k]+amount*(original[i+k]-blurred[i+k]), 0, 255))
+			}
+		}
+	}
+}
+
+// vignette darkens pixels further than radius from the centre, where 1 is the corners
+func vignette(strength float64, radius float64) filter {
+	return func(img *image.NRGBA) {
+		width, height := img.Rect.Dx(), img.Rect.Dy()
+		cx, cy := float64(width-1)/2, float64(height-1)/2
+		corner := math.Hypot(cx, cy)
+
+		for y := 0; y < height; y++ {
+			for x := 0; x < width; x++ {
+				d := math.Hypot(float64(x)-cx, float64(y)-cy) / corner
+				t := clamp((d-radius)/math.Max(1e-9, 1-radius), 0, 1)
+				shade := 1 - strength*t*t*(3-2*t)
+
+				i := img.PixOffset(x, y)
+				for k := 0; k < 3; k++ {
+					img.Pix[i+k] = uint8(clamp(float64(img.Pix[i+k])*shade, 0, 255))
+				}
+			}
+		}
+	}
+}
+
+// blur approximates a gaussian blur of an RGBA buffer with three passes of a box blur
+// in each direction.
+func blur(pix []float64, width int, height int, radius int) []float64 {
+	if radius < 1 {
+		return pix
+	}
+
+	out := make([]float64, len(pix))
+	copy(out, pix)
+	tmp := make([]float64, len(pix))
+
+	for pass := 0; pass < 3; pass++ {
+		boxBlur(out, tmp, width, height, radius, 4, width*4)
+		boxBlur(tmp, out, height, width, radius, width*4, 4)
+	}
+
+	return out
+}
+
+// boxBlur averages each pixel with radius pixels either side along lines of length pixels.
+// step is the distance between pixels on a line and stride the distance between lines.
+func boxBlur(src []float64, dst []float64, length int, lines int, radius int, step int, stride int) {
+	window := float64(2*radius + 1)
+
+	for line := 0; line < lines; line++ {
+		base := line * stride
+		for k := 0; k < 3; k++ {
+			var at = func(i int) float64 {
+				i = int(clamp(float64(i), 0, float64(length-1)))
+				return src[base+i*step+k]
+			}
+
+			var sum float64
+			for i := -radius; i <= radius; i++ {
+				sum += at(i)
+			}
+
+			for i := 0; i < length; i++ {
+				dst[base+i*step+k] = sum / window
+				sum += at(i+radius+1) - at(i-radius)
+			}
+		}
+		for i := 0; i < length; i++ {
+			dst[base+i*step+3] = src[base+i*step+3]
+		}
+	}
+}
@@ -0,0 +1,126 @@
+package main
+
+import (
+	"image"
+	"image/color"
+	"testing"
+)
+
+func TestPostProcessingChainAppliesInOrder(t *testing.T) {
+	filters, err := parsePostProcessing("levels:0:128, gamma:1")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	img := image.NewNRGBA(image.Rect(0, 0, 1, 1))
+	img.Pix = []uint8{64, 128, 200, 255}
+	postProcess(img, filters)
+
+	expected := []uint8{128, 255, 255, 255}
+	for i := range expected {
+		if img.Pix[i] != expected[i] {
+			t.Errorf("Channel %d was incorrect, got: %d, want: %d.", i, img.Pix[i], expected[i])
+		}
+	}
+}
+
+func TestPostProcessingRejectsUnknownFilters(t *testing.T) {
+	for _, chain := range []string{"blur:2", "gamma:x", "levels:200:100", "curve:0:0"} {
+		if _, err := parsePostProcessing(chain); err == nil {
+			t.Errorf("Expected an error for %q", chain)
+		}
+	}
+}
+
+// applyPostProcessing runs a chain over a grey image set by shade, returning each pixel's red channel
+func applyPostProcessing(t *testing.T, chain string, width int, height int, shade func(x int, y int) uint8) [][]uint8 {
+	filters, err := parsePostProcessing(chain)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	img := image.NewNRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			v := shade(x, y)
+			img.SetNRGBA(x, y, color.NRGBA{v, v, v, 255})
+		}
+	}
+	postProcess(img, filters)
+
+	var out = make([][]uint8, height)
+	for y := range out {
+		out[y] = make([]uint8, width)
+		for x := range out[y] {
+			out[y][x] = img.NRGBAAt(x, y).R
+		}
+	}
+	return out
+}
+
+func TestSharpenSteepensEdges(t *testing.T) {
+	out := applyPostProcessing(t, "sharpen:1:1", 13, 1, func(x int, y int) uint8 {
+		if x < 7 {
+			return 100
+		}
+		return 150
+	})[0]
+
+	if out[6] >= 100 || out[7] <= 150 {
+		t.Errorf("Either side of the edge was %d and %d, want darker than 100 and lighter than 150.", out[6], out[7])
+	}
+	if out[0] != 100 || out[12] != 150 {
+		t.Errorf("Far from the edge was %d and %d, want 100 and 150 unchanged.", out[0], out[12])
+	}
+}
+
+func TestGlowSpreadsOnlyBrightPixels(t *testing.T) {
+	var impulse = func(v uint8) func(x int, y int) uint8 {
+		return func(x int, y int) uint8 {
+			if x == 10 && y == 10 {
+				return v
+			}
+			return 0
+		}
+	}
+
+	out := applyPostProcessing(t, "glow:1:0.5:2", 21, 21, impulse(255))
+	if out[10][10] != 255 || out[10][11] == 0 || out[12][10] == 0 {
+		t.Errorf("Around a white pixel was %d, %d and %d, want it kept and its neighbours lit.", out[10][10], out[10][11], out[12][10])
+	}
+	if out[0][0] != 0 {
+		t.Errorf("The corner was %d, want it out of the glow's reach.", out[0][0])
+	}
+
+	out = applyPostProcessing(t, "glow:1:0.5:2", 21, 21, impulse(100))
+	if out[10][10] != 100 || out[10][11] != 0 {
+		t.Errorf("Around a pixel below the threshold was %d and %d, want no glow.", out[10][10], out[10][11])
+	}
+}
+
+func TestVignetteDarkensTheCorners(t *testing.T) {
+	out := applyPostProcessing(t, "vignette:0.5:0.5", 11, 11, func(x int, y int) uint8 { return 200 })
+
+	if out[5][5] != 200 || out[5][7] != 200 {
+		t.Errorf("Inside the radius was %d and %d, want 200 unchanged.", out[5][5], out[5][7])
+	}
+	for _, corner := range []uint8{out[0][0], out[0][10], out[10][0], out[10][10]} {
+		if corner != 100 {
+			t.Errorf("A corner was %d, want it darkened by half to 100.", corner)
+		}
+	}
+}
+
+func TestCurveMapsItsPoints(t *testing.T) {
+	levels := []uint8{0, 128, 255}
+	var shade = func(x int, y int) uint8 { return levels[x] }
+
+	if out := applyPostProcessing(t, "curve:0:20:128:128:255:235", 3, 1, shade)[0]; out[0] != 20 || out[1] != 128 || out[2] != 235 {
+		t.Errorf("Curve mapped 0, 128 and 255 to %v, want 20, 128 and 235.", out)
+	}
+
+	// Values outside the curve take its ends
+	if out := applyPostProcessing(t, "curve:64:0:192:255", 3, 1, shade)[0]; out[0] != 0 || out[2] != 255 {
+		t.Errorf("Curve mapped 0 and 255 to %d and %d, want 0 and 255.", out[0], out[2])
+	}
+}
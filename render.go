@@ -0,0 +1,186 @@
+package main
+
+import (
+	"fmt"
+	"image/color"
+	"math"
+	"strconv"
+)
+
+// An escapeTimeFractal describes a fractal drawn by applying an escape time function
+// to every point of a Plane
+type escapeTimeFractal struct {
+	name       string                // Prefix of the generated file name
+	calculate  escapeCalculator      // The escape time function
+	converge   convergenceCalculator // Convergence test used in place of calculate, when set
+	roots      int                   // Number of roots the convergence test can settle on
+	derivative derivativeCalculator  // Optional derivative of z at escape, used for analytic lighting normals
+	smoothing  smoothing             // Degree and escape radius for smooth colouring. Zeroes are estimated from the render
+	settles    bool                  // Escaped points settled rather than passed an escape radius, so aren't smoothed
+}
+
+// An escapeField holds the result of the escape time function for every pixel of a render
+type escapeField struct {
+	width  int
+	height int
+	points []PlottedPoint // Indexed by y*width + x
+}
+
+// at returns the point plotted at x, y, clamping to the edges of the field
+func (f *escapeField) at(x int, y int) PlottedPoint {
+	x = int(clamp(float64(x), 0, float64(f.width-1)))
+	y = int(clamp(float64(y), 0, float64(f.height-1)))
+	return f.points[y*f.width+x]
+}
+
+// plotter applies the fractal's escape or convergence test to a point
+func (f escapeTimeFractal) plotter() pointPlotter {
+	if f.converge != nil {
+		return func(p Point, config config) PlottedPoint {
+			var root, iteration, finalReal, finalImag = f.converge(p.real, p.imag, config)
+			return PlottedPoint{p.X, p.Y, finalReal, finalImag, iteration, root >= 0, root}
+		}
+	}
+
+	return func(p Point, config config) PlottedPoint {
+		var escaped, iteration, finalReal, finalImag = f.calculate(p.real, p.imag, config)
+		return PlottedPoint{p.X, p.Y, finalReal, finalImag, iteration, escaped, 0}
+	}
+}
+
+// estimateSmoothing returns the fractal's smoothing, estimating what it doesn't declare from
+// the field. Convergence tests have no escape radius, so are never smoothed.
+func (f escapeTimeFractal) estimateSmoothing(field *escapeField) smoothing {
+	if f.converge != nil || f.settles {
+		return smoothing{}
+	}
+	return estimateSmoothing(f.smoothing, field)
+}
+
+func (p *Plane) plotField(c config, plot pointPlotter) *escapeField {
+	field := &escapeField{c.width, c.height, make([]PlottedPoint, c.width*c.height)}
+
+	plottedChannel := make(chan PlottedPoint)
+	done := make(chan bool)
+
+	go func(points <-chan PlottedPoint) {
+		for p := range points {
+			field.points[p.Y*field.width+p.X] = p
+		}
+		done <- true
+	}(plottedChannel)
+
+	p.iterateOverPoints(c, plottedChannel, plot)
+	close(plottedChannel)
+	<-done
+
+	return field
+}
+
+func (p *Plane) render(c config, f escapeTimeFractal) {
+	filters, err := parsePostProcessing(c.postProcessing)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+
+	var interior *Gradient
+	if c.interiorGradient != "" {
+		g := initialiseGradient(c.interiorGradient, c.gradientSpace)
+		interior = &g
+	}
+
+	layers, err := loadLayers(c)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+
+	for _, l := range layers {
+		// Analytic normals divide by the height scale
+		if l.Lighting != noLighting && c.lightHeight == 0 {
+			fmt.Println("lighting: -lh must not be 0")
+			return
+		}
+	}
+
+	mbi := initialiseimage(c)
+
+	field := p.plotField(c, f.plotter())
+	s := f.estimateSmoothing(field)
+
+	var contexts = make([]*colouringContext, len(layers))
+	var lights = make([]light, len(layers))
+	for i, l := range layers {
+		lc := l.configure(c)
+		contexts[i] = newColouringContext(lc, initialiseGradient(lc.gradient, lc.gradientSpace), field, s)
+		contexts[i].roots = f.roots
+		lights[i] = newLight(lc)
+	}
+
+	// Only worth reporting when -gmin and -gmax haven't pinned both ends of the fit
+	if fit := contexts[0].config; fit.autoFit && (fit.gradientMin <= 0 || fit.gradientMax <= 0) {
+		fmt.Printf("Gradient fitted to escape counts %.0f to %.0f, pin with -gmin %.0f -gmax %.0f\n", contexts[0].domainMin, contexts[0].domainMax, contexts[0].domainMin, contexts[0].domainMax)
+	}
+
+	for _, point := range field.points {
+		if point.Escaped {
+			var colour = color.NRGBA{0, 0, 0, 255}
+			for i, l := range layers {
+				top := getPixelColour(point, contexts[i])
+				if l.Lighting != noLighting {
+					top = lights[i].illuminate(top, p.surfaceNormal(contexts[i].config, f, field, point, s))
+				}
+				colour = blend(l.Blend, colour, top, l.opacity())
+			}
+			mbi.Set(point.X, point.Y, colour)
+		} else if interior != nil {
+			mbi.Set(point.X, point.Y, getInteriorColour(point, *interior))
+		}
+	}
+
+	postProcess(mbi, filters)
+
+	if c.filename == "" {
+		c.filename = f.name + strconv.FormatFloat(c.midX, 'E', -1, 64) + "_" + strconv.FormatFloat(c.midY, 'E', -1, 64) + "_" + strconv.FormatFloat(c.zoom, 'E', -1, 64) + ".jpg"
+	}
+
+	description := describeRender(c, contexts[0].gradient)
+	saveimage(mbi, c.output, c.filename, description)
+
+	fmt.Printf("%s/%s\n", c.output, c.filename)
+
+	saveSimulation(mbi, c, description)
+}
+
+// describeRender records how an image was made, with the gradient written out in full
+// so generated gradients can be recovered and reused.
+func describeRender(c config, gradient Gradient) string {
+	var description = fmt.Sprintf("algorithm=%s\nr=%s\ni=%s\nz=%s\nm=%d\nc=%s\n",
+		c.algorithm,
+		strconv.FormatFloat(c.midX, 'E', -1, 64),
+		strconv.FormatFloat(c.midY, 'E', -1, 64),
+		strconv.FormatFloat(c.zoom, 'E', -1, 64),
+		c.maxIterations,
+		c.colourMode)
+
+	if c.layers != "" {
+		description += fmt.Sprintf("layers=%s\n", c.layers)
+	}
+
+	if c.postProcessing != "" {
+		description += fmt.Sprintf("post=%s\n", c.postProcessing)
+	}
+
+	if c.gradient != formatGradient(gradient.stops) {
+		description += fmt.Sprintf("gradientSource=%s\n", c.gradient)
+	}
+
+	return description + fmt.Sprintf("g=%s\n", formatGradient(gradient.stops))
+}
+
+// getInteriorColour colours a point that did not escape by the modulus of its final z,
+// taking the customary escape radius of 2 as the end of the gradient.
+func getInteriorColour(point PlottedPoint, gradient Gradient) color.NRGBA {
+	return gradient.colourAt(math.Sqrt(point.real*point.real+point.imag*point.imag) / 2.0)
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// A slice is a plane through the 4D space of (c, z0) that every formula lives in. The point
+// (r, i) of a Plane maps to origin + r*u + i*v, with each vector given as
+// Re(c), Im(c), Re(z0), Im(z0). The Mandelbrot form is the slice 0,0,0,0;1,0,0,0;0,1,0,0 and
+// the Julia form of c is cr,ci,0,0;0,0,1,0;0,0,0,1.
+type slice struct {
+	origin [4]float64
+	u      [4]float64
+	v      [4]float64
+}
+
+// parseSlice reads a slice given as origin;u;v, each vector a comma separated list of four numbers
+func parseSlice(str string) (slice, error) {
+	var s slice
+
+	vectors := strings.Split(str, ";")
+	if len(vectors) != 3 {
+		return s, fmt.Errorf("slice: expected origin;u;v, got %d vectors", len(vectors))
+	}
+
+	for n, target := range []*[4]float64{&s.origin, &s.u, &s.v} {
+		components := strings.Split(vectors[n], ",")
+		if len(components) != 4 {
+			return s, fmt.Errorf("slice: vector %d needs Re(c),Im(c),Re(z0),Im(z0), got %q", n+1, vectors[n])
+		}
+
+		for i, component := range components {
+			var err error
+			if target[i], err = strconv.ParseFloat(strings.TrimSpace(component), 64); err != nil {
+				return s, fmt.Errorf("slice: vector %d has an invalid value %q", n+1, component)
+			}
+		}
+	}
+
+	return s, nil
+}
+
+// at maps a point of the plane to its c and z0
+func (s slice) at(real float64, imag float64) (cReal float64, cImag float64, zReal float64, zImag float64) {
+	var p [4]float64
+	for k := range p {
+		p[k] = s.origin[k] + real*s.u[k] + imag*s.v[k]
+	}
+	return p[0], p[1], p[2], p[3]
+}
+
+// sliceForm draws the formula over a slice. Escape radii depend only on c, and grow with |c|,
+// so the whole render escapes at the radius for the view's corner furthest out in c. That
+// keeps one radius for smooth colouring.
+func (f formula) sliceForm(c config, p *Plane, s slice) escapeTimeFractal {
+	var bailout = f.bailout
+	for _, corner := range [][2]int{{0, 0}, {c.width - 1, 0}, {0, c.height - 1}, {c.width - 1, c.height - 1}} {
+		cReal, cImag, _, _ := s.at(p.coordinatesAt(c, corner[0], corner[1]))
+		bailout = math.Max(bailout, f.juliaBailout(cReal, cImag))
+	}
+
+	var calculate escapeCalculator = func(real float64, imag float64, config config) (bool, int, float64, float64) {
+		cReal, cImag, zReal, zImag := s.at(real, imag)
+		return f.orbit(zReal, zImag, cReal, cImag, bailout, config)
+	}
+
+	return escapeTimeFractal{name: f.name + "slice_", calculate: calculate, smoothing: f.smoothing(bailout), settles: f.settles}
+}
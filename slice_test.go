@@ -0,0 +1,47 @@
+package main
+
+import "testing"
+
+func TestParseSliceMapsPointsThroughTheBasis(t *testing.T) {
+	s, err := parseSlice("0.5,0,0,0.25;1,0,0,0;0,0,1,0")
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	cReal, cImag, zReal, zImag := s.at(2.0, 3.0)
+	if cReal != 2.5 || cImag != 0.0 || zReal != 3.0 || zImag != 0.25 {
+		t.Errorf("Point was c = %f%+fi, z0 = %f%+fi, want c = 2.5+0i, z0 = 3+0.25i.", cReal, cImag, zReal, zImag)
+	}
+}
+
+func TestParseSliceRejectsMalformedVectors(t *testing.T) {
+	for _, str := range []string{"", "0,0,0,0;1,0,0,0", "0,0,0;1,0,0,0;0,1,0,0", "0,0,0,x;1,0,0,0;0,1,0,0"} {
+		if _, err := parseSlice(str); err == nil {
+			t.Errorf("Slice %q parsed, want an error.", str)
+		}
+	}
+}
+
+func TestJuliaSliceMatchesTheJuliaForm(t *testing.T) {
+	m := newMandelbrot()
+	var c config
+	c.maxIterations = 200
+	c.bailout = 2.0
+	c.width, c.height, c.zoom = 10, 10, 1.0
+	c.constR, c.constI = -0.8, 0.156
+
+	f := m.formula(c)
+	s, _ := parseSlice("-0.8,0.156,0,0;0,0,1,0;0,0,0,1")
+	sliced := f.sliceForm(c, &m.Plane, s)
+
+	c.bailout = f.juliaBailout(c.constR, c.constI)
+	julia := f.juliaForm(c)
+
+	for _, p := range [][2]float64{{0.0, 0.0}, {0.3, -0.2}, {1.5, 1.5}} {
+		escapedS, iterationsS, _, _ := sliced.calculate(p[0], p[1], c)
+		escapedJ, iterationsJ, _, _ := julia.calculate(p[0], p[1], c)
+		if escapedS != escapedJ || iterationsS != iterationsJ {
+			t.Errorf("At %v the slice gave %t after %d, the Julia form %t after %d.", p, escapedS, iterationsS, escapedJ, iterationsJ)
+		}
+	}
+}
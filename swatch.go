@@ -0,0 +1,67 @@
+package main
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"math"
+)
+
+// renderSwatch saves a swatch of the configured gradient
+func renderSwatch(c config) {
+	gradient := initialiseGradient(c.gradient, c.gradientSpace)
+	mbi := drawSwatch(gradient, c)
+
+	if c.filename == "" {
+		c.filename = "swatch.jpg"
+	}
+
+	description := fmt.Sprintf("g=%s\n", formatGradient(gradient.stops))
+	saveimage(mbi, c.output, c.filename, description)
+
+	fmt.Printf("%s/%s\n", c.output, c.filename)
+
+	saveSimulation(mbi, c, description)
+}
+
+// drawSwatch draws a gradient as a horizontal swatch. The top band is the continuous gradient
+// used by true colouring, the middle marks the stop positions and the bottom shows the palette
+// smooth and banded colouring sample from it. The swatch is a fifth as tall as it is wide.
+func drawSwatch(gradient Gradient, c config) *image.NRGBA {
+	palette := fillPalette(gradient, c)
+
+	width := c.width
+	height := int(math.Max(30, float64(width/5)))
+	continuousHeight := height * 9 / 20
+	markerHeight := height / 10
+
+	bounds := image.Rect(0, 0, width, height)
+	mbi := image.NewNRGBA(bounds)
+	draw.Draw(mbi, bounds, image.NewUniform(color.NRGBA{32, 32, 32, 255}), image.ZP, draw.Src)
+
+	for x := 0; x < width; x++ {
+		position := float64(x) / math.Max(1, float64(width-1))
+
+		colour := gradient.colourAt(position)
+		for y := 0; y < continuousHeight; y++ {
+			mbi.Set(x, y, colour)
+		}
+
+		entry := palette[int(math.Min(float64(len(palette)-1), position*float64(len(palette))))]
+		for y := continuousHeight + markerHeight; y < height; y++ {
+			mbi.Set(x, y, entry)
+		}
+	}
+
+	for _, s := range gradient.stops {
+		x := int(math.Round(s.position * float64(width-1)))
+		for dx := -1; dx <= 1; dx++ {
+			for y := continuousHeight; y < continuousHeight+markerHeight; y++ {
+				mbi.Set(x+dx, y, color.NRGBA{255, 255, 255, 255})
+			}
+		}
+	}
+
+	return mbi
+}
@@ -0,0 +1,44 @@
+package main
+
+import (
+	"image/color"
+	"testing"
+)
+
+func TestSwatchShowsTheGradientStopsAndPalette(t *testing.T) {
+	var c config
+	c.width = 200
+	c.paletteLength = 4
+	c.paletteRepeat = 1
+
+	gradient := initialiseGradient(`[["0.0", "000000"],["0.5", "ff0000"],["1.0", "ffffff"]]`, rgbInterpolation)
+	palette := fillPalette(gradient, c)
+	mbi := drawSwatch(gradient, c)
+
+	// A fifth as tall as it is wide: the gradient in rows 0 to 17, markers in 18 to 21 and
+	// the palette below
+	if size := mbi.Bounds().Size(); size.X != 200 || size.Y != 40 {
+		t.Fatalf("Swatch was %d by %d, want 200 by 40.", size.X, size.Y)
+	}
+
+	cases := []struct {
+		x, y int
+		want color.NRGBA
+	}{
+		{0, 0, gradient.colourAt(0)},
+		{199, 17, gradient.colourAt(1)},
+		{120, 5, gradient.colourAt(120.0 / 199)},
+		{0, 19, color.NRGBA{255, 255, 255, 255}},   // Marker of the stop at 0
+		{100, 20, color.NRGBA{255, 255, 255, 255}}, // Marker of the stop at 0.5, rounded to x = 100
+		{50, 19, color.NRGBA{32, 32, 32, 255}},     // Background between markers
+		{10, 30, palette[0]},
+		{60, 39, palette[1]},
+		{199, 22, palette[3]},
+	}
+
+	for _, tc := range cases {
+		if got := mbi.NRGBAAt(tc.x, tc.y); got != tc.want {
+			t.Errorf("Swatch at %d, %d was %v, want %v.", tc.x, tc.y, got, tc.want)
+		}
+	}
+}
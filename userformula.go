@@ -0,0 +1,89 @@
+package main
+
+import (
+	"fmt"
+)
+
+// An expressionPlane represents the strongly typed planar space for a formula given as an
+// expression with -expr
+type expressionPlane struct {
+	Plane
+}
+
+func newExpression() expressionPlane {
+	return expressionPlane{Plane{-2.5, 1.5, -2.0, 2.0}}
+}
+
+func (m *expressionPlane) process(c config) {
+	f, err := m.formula(c)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+
+	m.processFormula(c, f)
+}
+
+// formula compiles the expressions in the config. In the Mandelbrot form pixel is c, and in the
+// Julia form it is the starting z. Slices take pixel to be c. An escape condition may just as
+// well test for orbits settling, so escape counts are only smoothed without one.
+func (m *expressionPlane) formula(c config) (formula, error) {
+	params, err := parseParams(c.params)
+	if err != nil {
+		return formula{}, err
+	}
+
+	step, err := compileExpression(c.expression, params)
+	if err != nil {
+		return formula{}, fmt.Errorf("formula: %s", err)
+	}
+
+	start, err := compileExpression(c.start, params)
+	if err != nil {
+		return formula{}, fmt.Errorf("starting z: %s", err)
+	}
+
+	var escape expression
+	if c.escapeCondition != "" {
+		if escape, err = compileExpression(c.escapeCondition, params); err != nil {
+			return formula{}, fmt.Errorf("escape condition: %s", err)
+		}
+	}
+
+	var julia = c.julia && c.slice == ""
+	var orbit orbitCalculator = func(zr float64, zi float64, cr float64, ci float64, bailout float64, config config) (bool, int, float64, float64) {
+		var env = expressionEnv{z: complex(zr, zi), c: complex(cr, ci), pixel: complex(cr, ci)}
+		if julia {
+			env.pixel = env.z
+		}
+
+		var iteration int
+		for iteration = 0; iteration < config.maxIterations; iteration++ {
+			if escape != nil {
+				if real(escape(&env)) != 0 {
+					break
+				}
+			} else if real(env.z)*real(env.z)+imag(env.z)*imag(env.z) > bailout {
+				break
+			}
+			env.z = step(&env)
+		}
+
+		return iteration < config.maxIterations, iteration, real(env.z), imag(env.z)
+	}
+
+	var bailout = c.bailout * c.bailout
+
+	return formula{
+		name:  "formula_",
+		orbit: orbit,
+		start: func(r float64, i float64) (float64, float64) {
+			z := start(&expressionEnv{c: complex(r, i), pixel: complex(r, i)})
+			return real(z), imag(z)
+		},
+		bailout:      bailout,
+		juliaBailout: func(cReal float64, cImag float64) float64 { return bailout },
+		juliaPlane:   Plane{-2.0, 2.0, -2.0, 2.0},
+		settles:      escape != nil,
+	}, nil
+}
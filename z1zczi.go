@@ -1,9 +1,8 @@
 package main
 
 import (
-	"fmt"
+	"math"
 	"math/cmplx"
-	"strconv"
 )
 
 type z1ZcZiPlane struct {
@@ -15,56 +14,35 @@ func newZ1ZcZi() z1ZcZiPlane {
 }
 
 func (m z1ZcZiPlane) process(c config) {
-	if c.midX == -99.0 {
-		c.midX = (m.rMax + m.rMin) / 2.0
-	}
-
-	if c.midY == -99.0 {
-		c.midY = (m.iMax + m.iMin) / 2.0
-	}
-
-	if c.mode == imageMode {
-		m.image(c)
-	} else if c.mode == coordinatesMode {
-		var r, i = m.calculateCoordinatesAtPoint(c)
-		fmt.Printf("%18.17e, %18.17e\n", r, i)
-	}
+	m.processFormula(c, m.formula())
 }
 
-func (m *z1ZcZiPlane) image(c config) {
-	initialiseGradient(c.gradient)
-
-	mbi := initialiseimage(c)
-
-	plottedChannel := make(chan PlottedPoint)
-
-	go func(points <-chan PlottedPoint) {
-		for p := range points {
-			if p.Escaped {
-				mbi.Set(p.X, p.Y, getPixelColour(p, c.maxIterations, c.colourMode))
-			}
-		}
-	}(plottedChannel)
-
-	var checkIfPointEscapes escapeCalculator = func(r float64, imaginary float64, config config) (bool, int, float64, float64) {
-		var z = complex(0.0, 0.0)
-		var c = complex(r, imaginary)
-		var count int
-
-		for count = 0; count < config.maxIterations && cmplx.Abs(z) < 4.0; count++ {
-			z = (z + 1.0) * (z + c) * (z + complex(0.0, 1.0))
-		}
-
-		return count < config.maxIterations, count, real(z), imag(z)
+func (m *z1ZcZiPlane) formula() formula {
+	return formula{
+		name:    "z1zczi_mb_",
+		orbit:   z1ZcZiOrbit,
+		start:   startAtZero,
+		bailout: 16.0,
+		// Past 3 + |c| each factor is at least 2, so the orbit grows by at least 4|z| a step
+		juliaBailout: func(cReal float64, cImag float64) float64 {
+			radius := math.Max(4, 3+math.Hypot(cReal, cImag))
+			return radius * radius
+		},
+		degree:     3.0,
+		juliaPlane: Plane{-2.5, 2.5, -2.5, 2.5},
 	}
+}
 
-	m.iterateOverPoints(c, plottedChannel, checkIfPointEscapes)
+// z1ZcZiOrbit iterates (z + 1)(z + c)(z + i)
+func z1ZcZiOrbit(zr float64, zi float64, r float64, imaginary float64, bailout float64, config config) (bool, int, float64, float64) {
+	var z = complex(zr, zi)
+	var c = complex(r, imaginary)
+	var radius = math.Sqrt(bailout)
+	var count int
 
-	if c.filename == "" {
-		c.filename = "z1zczi_mb_" + strconv.FormatFloat(c.midX, 'E', -1, 64) + "_" + strconv.FormatFloat(c.midY, 'E', -1, 64) + "_" + strconv.FormatFloat(c.zoom, 'E', -1, 64) + ".jpg"
+	for count = 0; count < config.maxIterations && cmplx.Abs(z) < radius; count++ {
+		z = (z + 1.0) * (z + c) * (z + complex(0.0, 1.0))
 	}
 
-	saveimage(mbi, c.output, c.filename)
-
-	fmt.Printf("%s/%s\n", c.output, c.filename)
+	return count < config.maxIterations, count, real(z), imag(z)
 }